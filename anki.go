@@ -0,0 +1,133 @@
+package cccedictparser
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strings"
+)
+
+// AnkiFields are the fields of the notes written by WriteAnki, in order.
+// Key identifies an entry so importing an updated deck updates its notes.
+var AnkiFields = []string{"Key", "Simplified", "Traditional", "Pinyin", "Colored", "Gloss", "Classifiers", "Tags"}
+
+// AnkiOptions fills the header of an Anki import file.
+type AnkiOptions struct {
+	// Deck and NoteType preselect the deck and note type of the import.
+	Deck     string
+	NoteType string
+	// Tags are added to the usage tags of every note.
+	Tags []string
+}
+
+// ankiField keeps a value in its column: tabs and line breaks become spaces.
+func ankiField(s string) string {
+	return strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+}
+
+// ankiTag makes a tag out of s; Anki separates tags with spaces.
+func ankiTag(s string) string {
+	return strings.Join(strings.Fields(s), "_")
+}
+
+// ankiKey is the start of the cc-cedict line of ci, which is unique in the
+// dictionary: "中國 中国 [Zhong1 guo2]".
+func ankiKey(ci Ci) string {
+	return ci.Fantizi + " " + ci.Jiantizi + " [" + ci.PinyinRaw + "]"
+}
+
+// ankiColored renders the headwords and the pinyin colored by tone.
+func ankiColored(ci Ci) string {
+	var b strings.Builder
+	b.WriteString(`<div class="hanzi">`)
+	b.WriteString(ci.HanziToneHTML(ci.Jiantizi))
+	if ci.Fantizi != ci.Jiantizi {
+		b.WriteString(" (" + ci.HanziToneHTML(ci.Fantizi) + ")")
+	}
+	b.WriteString(`</div><div class="pinyin">`)
+	b.WriteString(ci.ToneHTML())
+	b.WriteString(`</div>`)
+	return b.String()
+}
+
+// ankiClassifiers lists the classifiers of ci as "个(個) gè, 位 wèi".
+func ankiClassifiers(ci Ci) string {
+	cls := ci.Classifiers()
+	items := make([]string, 0, len(cls))
+	for _, c := range cls {
+		s := c.Jiantizi
+		if c.Fantizi != c.Jiantizi {
+			s += "(" + c.Fantizi + ")"
+		}
+		words := make([]string, 0, len(c.Pinyin))
+		for _, w := range c.Pinyin {
+			words = append(words, w.Diacritic())
+		}
+		items = append(items, s+" "+strings.Join(words, " "))
+	}
+	return html.EscapeString(strings.Join(items, ", "))
+}
+
+// ankiGloss renders the senses of ci, without classifiers, as a list.
+func ankiGloss(ci Ci) string {
+	var b strings.Builder
+	b.WriteString("<ol>")
+	for _, g := range ci.Senses() {
+		b.WriteString("<li>" + html.EscapeString(g) + "</li>")
+	}
+	b.WriteString("</ol>")
+	return b.String()
+}
+
+// AnkiNote returns the fields of the note of ci, in the order of AnkiFields.
+// All fields but Tags are HTML.
+func AnkiNote(ci Ci, opts AnkiOptions) []string {
+	tags := make([]string, 0, len(opts.Tags)+2)
+	for _, t := range append(ci.Tags(), opts.Tags...) {
+		if t = ankiTag(t); t != "" && !slices.Contains(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+
+	return []string{
+		html.EscapeString(ankiKey(ci)),
+		html.EscapeString(ci.Jiantizi),
+		html.EscapeString(ci.Fantizi),
+		html.EscapeString(ci.DiacriticPinyin()),
+		ankiColored(ci),
+		ankiGloss(ci),
+		ankiClassifiers(ci),
+		strings.Join(tags, " "),
+	}
+}
+
+// WriteAnki writes entries as an Anki text import file: tab separated notes
+// with the fields of AnkiFields, preceded by the header lines Anki reads
+// (separator, html, columns, tags column, and deck and note type if set).
+func WriteAnki(w io.Writer, entries []Ci, opts AnkiOptions) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "#separator:tab\n#html:true\n")
+	if opts.NoteType != "" {
+		fmt.Fprintf(bw, "#notetype:%s\n", ankiField(opts.NoteType))
+	}
+	if opts.Deck != "" {
+		fmt.Fprintf(bw, "#deck:%s\n", ankiField(opts.Deck))
+	}
+	fmt.Fprintf(bw, "#columns:%s\n", strings.Join(AnkiFields, "\t"))
+	fmt.Fprintf(bw, "#tags column:%d\n", len(AnkiFields))
+
+	for _, ci := range entries {
+		fields := AnkiNote(ci, opts)
+		for i := range fields {
+			fields[i] = ankiField(fields[i])
+		}
+		if _, err := io.WriteString(bw, strings.Join(fields, "\t")+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
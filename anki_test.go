@@ -0,0 +1,90 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAnki(t *testing.T) {
+	tests := []testItem{
+		{Name: "anki_Note", Test: anki_Note},
+		{Name: "anki_Write", Test: anki_Write},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func anki_Note(t *testing.T) {
+	ci, err := ParseLine("哥們兒 哥们儿 [ge1 men5 r5] /(coll.) brothers/buddies/CL:個|个[ge4]/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := AnkiNote(ci, AnkiOptions{Tags: []string{"HSK 5", "coll"}})
+	if len(fields) != len(AnkiFields) {
+		t.Fatalf("expected %d fields, got %d", len(AnkiFields), len(fields))
+	}
+
+	expected := map[string]string{
+		"Key":         "哥們兒 哥们儿 [ge1 men5 r5]",
+		"Simplified":  "哥们儿",
+		"Traditional": "哥們兒",
+		"Pinyin":      "gē men r",
+		"Gloss":       "<ol><li>(coll.) brothers</li><li>buddies</li></ol>",
+		"Classifiers": "个(個) gè",
+		"Tags":        "coll HSK_5",
+	}
+	for i, name := range AnkiFields {
+		if want, ok := expected[name]; ok && fields[i] != want {
+			t.Errorf("%s: expected %s, got %s", name, want, fields[i])
+		}
+	}
+
+	colored := fields[4]
+	if !strings.Contains(colored, `<span class="tone1" style="color:#e30000">哥</span>`) || !strings.Contains(colored, `(<span class="tone1" style="color:#e30000">哥</span>`) {
+		t.Errorf("unexpected colored field %s", colored)
+	}
+}
+
+func anki_Write(t *testing.T) {
+	entries := loadSample(t)
+
+	var buf bytes.Buffer
+	if err := WriteAnki(&buf, entries, AnkiOptions{Deck: "Chinese::CC-CEDICT", NoteType: "CC-CEDICT"}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	header := []string{
+		"#separator:tab",
+		"#html:true",
+		"#notetype:CC-CEDICT",
+		"#deck:Chinese::CC-CEDICT",
+		"#columns:" + strings.Join(AnkiFields, "\t"),
+		"#tags column:8",
+	}
+	if len(lines) != len(header)+len(entries) {
+		t.Fatalf("expected %d lines, got %d", len(header)+len(entries), len(lines))
+	}
+	for i, h := range header {
+		if lines[i] != h {
+			t.Errorf("header %d: expected %s, got %s", i, h, lines[i])
+		}
+	}
+
+	keys := make(map[string]bool)
+	for _, l := range lines[len(header):] {
+		fields := strings.Split(l, "\t")
+		if len(fields) != len(AnkiFields) {
+			t.Errorf("expected %d fields, got %d: %s", len(AnkiFields), len(fields), l)
+			continue
+		}
+		if keys[fields[0]] {
+			t.Errorf("duplicate key %s", fields[0])
+		}
+		keys[fields[0]] = true
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"golang.org/x/text/unicode/norm"
 )
@@ -101,15 +102,6 @@ const section_pinyin = 5
 const section_transition_gloss = 6
 const section_gloss = 7
 
-// true if not out of bounds
-// false if out of bounds
-func tryPeak(arr []rune, index int) (rune, bool) {
-	if index >= len(arr) {
-		return 0, false
-	}
-	return arr[index], true
-}
-
 func (p PinyinV1) String() string {
 	return fmt.Sprintf("PinyinV1{Sound:\"%s\", Tone: %d, Type: %d}", p.Sound, p.Tone, p.Type)
 }
@@ -134,56 +126,27 @@ func (ci Ci) String() string {
 	return fmt.Sprintf("Ci{Fantizi:\"%s\", Jiantizi:\"%s\", Pinyin:%s, PinyinRaw:\"%s\", Gloss:[%s], FormatVersion:%s}", ci.Fantizi, ci.Jiantizi, pyV2ArrStr(ci.Pinyin), ci.PinyinRaw, strings.Join(ci.Gloss, ", "), ci.FormatVersion)
 }
 
-func pinyinV1StrToPinyin(pys string) ([]PinyinV2, error) {
-	items := strings.Split(pys, " ")
-
-	pyItems := make([]PinyinV2, 0, len(items))
-
-	runes := make([]rune, 0, 10)
-
-	//ci2 shu1
-	for _, v := range items {
-		//ci2
-		runes = runes[:0]
-		for _, c := range v {
-			runes = append(runes, c)
-		}
-
-		py, err := getPyV1ForPySegmentRunes(runes)
-
-		if err != nil {
-			return []PinyinV2{}, err
-		}
-
-		pyItems = append(pyItems, PinyinV2{
-			Word: []PinyinV1{py},
-		})
+// FormatLine writes ci back as a cc-cedict line, the inverse of ParseLine.
+func FormatLine(ci Ci) string {
+	pinyinStart, pinyinEnd := "[", "]"
+	if ci.FormatVersion == V2 {
+		pinyinStart, pinyinEnd = "[[", "]]"
 	}
-
-	return pyItems, nil
+	return fmt.Sprintf("%s %s %s%s%s /%s/", ci.Fantizi, ci.Jiantizi, pinyinStart, ci.PinyinRaw, pinyinEnd, strings.Join(ci.Gloss, "/"))
 }
 
-func getTone(s rune) (Tone, error) {
+var errUnrecognizedTone = errors.New("unrecognized tone")
 
-	switch s {
-	case 49:
-		return T1, nil
-	case 50:
-		return T2, nil
-	case 51:
-		return T3, nil
-	case 52:
-		return T4, nil
-	case 53:
-		return T5, nil
-	default:
-		return None, errors.New("unrecognized tone")
+func getTone(s rune) (Tone, error) {
+	if s >= '1' && s <= '5' {
+		return Tone(s - '0'), nil
 	}
+	return None, errUnrecognizedTone
 }
 
 func soundIsAlphabetic(str string) bool {
-	for _, v := range str {
-		if !((v >= 97 && v <= 122) || (v >= 65 && v <= 90)) {
+	for i := 0; i < len(str); i++ {
+		if c := str[i]; !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')) {
 			return false
 		}
 	}
@@ -191,23 +154,22 @@ func soundIsAlphabetic(str string) bool {
 }
 
 func soundHasNumber(str string) bool {
-	for _, v := range str {
-		if v >= 48 && v <= 57 {
+	for i := 0; i < len(str); i++ {
+		if str[i] >= '0' && str[i] <= '9' {
 			return true
 		}
 	}
 	return false
 }
 
-func getPyV1ForPySegmentRunes(runes []rune) (PinyinV1, error) {
-	hasTone := false
-	var tone Tone
-
-	if len(runes) == 0 {
+// getPyV1ForPySegment parses one syllable such as "zhong1", "u:3", "xx5" or
+// "{x}". The sound is a substring of seg unless "u:" had to be replaced.
+func getPyV1ForPySegment(seg string) (PinyinV1, error) {
+	if len(seg) == 0 {
 		return PinyinV1{}, errors.New("no runes provided")
 	}
 
-	if string(runes) == "xx5" {
+	if seg == "xx5" {
 		return PinyinV1{
 			Sound: "xx",
 			Tone:  T5,
@@ -215,19 +177,13 @@ func getPyV1ForPySegmentRunes(runes []rune) (PinyinV1, error) {
 		}, nil
 	}
 
-	if toneVal, err := getTone(runes[len(runes)-1]); err == nil {
-		hasTone = true
-		tone = toneVal
-	}
-
-	var sound string
-	if !hasTone {
-		sound = string(runes)
-	} else {
-		sound = string(runes[:len(runes)-1])
+	sound := seg
+	tone, err := getTone(rune(seg[len(seg)-1]))
+	if err == nil {
+		sound = seg[:len(seg)-1]
 	}
 
-	sound = strings.Replace(sound, "u:", "v", -1)
+	sound = strings.ReplaceAll(sound, "u:", "v")
 
 	startsWithBrackets := strings.HasPrefix(sound, "{")
 	endsWithBrakets := strings.HasSuffix(sound, "}")
@@ -242,9 +198,7 @@ func getPyV1ForPySegmentRunes(runes []rune) (PinyinV1, error) {
 
 	isAlphabetic := soundIsAlphabetic(sound)
 
-	hasNumber := soundHasNumber(sound)
-
-	if hasNumber && len(sound) != 1 {
+	if soundHasNumber(sound) && len(sound) != 1 {
 		return PinyinV1{}, errors.New("malformed pinyin v1")
 	}
 
@@ -257,120 +211,169 @@ func getPyV1ForPySegmentRunes(runes []rune) (PinyinV1, error) {
 		t = Special
 	}
 
-	py := PinyinV1{
+	return PinyinV1{
 		Sound: sound,
 		Tone:  tone,
 		Type:  t,
+	}, nil
+}
+
+// pinyinV1StrToPinyin parses "ci2 shu1": one syllable per word. All the
+// syllables share one backing array.
+func pinyinV1StrToPinyin(pys string) ([]PinyinV2, error) {
+	n := strings.Count(pys, " ") + 1
+	pyItems := make([]PinyinV2, n)
+	syllables := make([]PinyinV1, n)
+
+	i := 0
+	for v := range strings.SplitSeq(pys, " ") {
+		py, err := getPyV1ForPySegment(v)
+		if err != nil {
+			return []PinyinV2{}, err
+		}
+
+		syllables[i] = py
+		pyItems[i] = PinyinV2{Word: syllables[i : i+1 : i+1]}
+		i++
 	}
 
-	return py, nil
+	return pyItems, nil
 }
 
+// pinyinV2StrToPinyin parses "Ping2guo3 shou3ji1": words of syllables ended
+// by a tone number or "-", or wrapped in braces. Syllables are gathered on the
+// stack and copied into one backing array shared by the words.
 func pinyinV2StrToPinyin(pys string) ([]PinyinV2, error) {
-	words := strings.Split(pys, " ")
-
-	v2List := make([]PinyinV2, 0, len(words))
-
-	wordsForPyV2 := make([]PinyinV1, 0, len(words)*6)
+	var syllableScratch [64]PinyinV1
+	var endScratch [32]int
+	syllables := syllableScratch[:0]
+	ends := endScratch[:0]
 
-	runesBuilder := make([]rune, 0, 10)
-	pyItems := make([][]rune, 0, len(words)*6)
+	add := func(seg string) error {
+		item, err := getPyV1ForPySegment(seg)
+		if err != nil {
+			return err
+		}
+		if item.Sound == `·` {
+			return errors.New("malformed pinyin v2 - no dots")
+		}
+		syllables = append(syllables, item)
+		return nil
+	}
 
 	//Ping2guo3 shou3ji1
-	for _, word := range words {
-		wordsForPyV2 = wordsForPyV2[:0]
-
+	for word := range strings.SplitSeq(pys, " ") {
 		//Ping2guo3
-		runesBuilder := runesBuilder[:0]
-		pyItems := pyItems[:0]
+		start := 0
 		openBracket := false
-		for _, c := range word {
-			runesBuilder = append(runesBuilder, c)
-			sc := string(c)
 
-			if sc == "{" {
+		// the delimiters are ASCII, so they never match inside a multi-byte rune
+		for i := 0; i < len(word); i++ {
+			c := word[i]
+			if c == '{' {
 				openBracket = true
 			}
 
-			_, err := getTone(c)
-
-			if err == nil || (openBracket && sc == "}") || sc == "-" {
-				removeSuffix := 0
-				if openBracket || sc == "-" {
-					removeSuffix = 1
-				}
-				removePrefix := 0
-				if openBracket {
-					removePrefix = 1
-				}
-
-				cleanedRunes := runesBuilder[removePrefix : len(runesBuilder)-removeSuffix]
-				if len(cleanedRunes) != 0 {
-					api := make([]rune, len(cleanedRunes))
-					copy(api, cleanedRunes)
-					pyItems = append(pyItems, api)
-				} else {
-					//likely a special character
-					api := make([]rune, len(runesBuilder))
-					copy(api, runesBuilder)
-					pyItems = append(pyItems, api)
-				}
-				runesBuilder = runesBuilder[:0]
-				openBracket = false
+			_, err := getTone(rune(c))
+			if err != nil && !(openBracket && c == '}') && c != '-' {
+				continue
 			}
-		}
-
-		if len(runesBuilder) != 0 {
-			api := make([]rune, len(runesBuilder))
-			copy(api, runesBuilder)
-			pyItems = append(pyItems, api)
-			runesBuilder = runesBuilder[:0]
-		}
 
-		for _, pyItem := range pyItems {
-			item, err := getPyV1ForPySegmentRunes(pyItem)
+			seg := word[start : i+1]
+			cleaned := seg
+			if openBracket {
+				_, size := utf8.DecodeRuneInString(seg)
+				cleaned = seg[size : len(seg)-1]
+			} else if c == '-' {
+				cleaned = seg[:len(seg)-1]
+			}
+			if cleaned == "" {
+				//likely a special character
+				cleaned = seg
+			}
 
-			if err != nil {
+			if err := add(cleaned); err != nil {
 				return []PinyinV2{}, err
 			}
+			start = i + 1
+			openBracket = false
+		}
 
-			if item.Sound == `·` {
-				return []PinyinV2{}, errors.New("malformed pinyin v2 - no dots")
+		if start < len(word) {
+			if err := add(word[start:]); err != nil {
+				return []PinyinV2{}, err
 			}
-
-			wordsForPyV2 = append(wordsForPyV2, item)
 		}
 
-		nw := make([]PinyinV1, len(wordsForPyV2))
-		copy(nw, wordsForPyV2)
+		ends = append(ends, len(syllables))
+	}
+
+	shared := make([]PinyinV1, len(syllables))
+	copy(shared, syllables)
 
-		v2List = append(v2List, PinyinV2{
-			Word: nw,
-		})
+	v2List := make([]PinyinV2, len(ends))
+	start := 0
+	for i, end := range ends {
+		v2List[i] = PinyinV2{Word: shared[start:end:end]}
+		start = end
 	}
 
 	return v2List, nil
 }
 
 func makePyMap() map[string]bool {
-	pym := make(map[string]bool)
+	pym := make(map[string]bool, len(full_pinyin_list))
 	for _, v := range full_pinyin_list {
 		pym[v] = true
 	}
 	return pym
 }
 
-func ParseLine(line string) (Ci, error) {
-	pym := makePyMap()
+// pinyin_syllables is the set of valid syllables, built once and only read
+// afterwards so that every parser can share it.
+var pinyin_syllables = makePyMap()
+
+// isPinyinSyllable reports whether sound, in any case, is in pinyinVals.
+// Sounds are ASCII letters, lowered in a stack buffer.
+func isPinyinSyllable(pinyinVals map[string]bool, sound string) bool {
+	var buf [8]byte
+	if len(sound) > len(buf) {
+		return false
+	}
+	for i := 0; i < len(sound); i++ {
+		c := sound[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		buf[i] = c
+	}
+	return pinyinVals[string(buf[:len(sound)])]
+}
 
-	return parseLine(pym, line)
+// hasDecomposableRune reports whether s holds a rune which NFD would change,
+// such as a precomposed tone mark.
+func hasDecomposableRune(s string) bool {
+	for i := 0; i < len(s); {
+		if s[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r != utf8.RuneError && !norm.NFD.IsNormalString(s[i:i+size]) {
+			return true
+		}
+		i += size
+	}
+	return false
 }
 
-func NewLineParser() LineParser {
-	pym := makePyMap()
+func ParseLine(line string) (Ci, error) {
+	return parseLine(pinyin_syllables, line)
+}
 
+func NewLineParser() LineParser {
 	return basicLineParser{
-		Pym: pym,
+		Pym: pinyin_syllables,
 	}
 }
 
@@ -378,133 +381,146 @@ func (blp basicLineParser) ParseLine(line string) (Ci, error) {
 	return parseLine(blp.Pym, line)
 }
 
+// lineSections holds the byte offsets of the parts of a line, as found by
+// scanLine. Each part is [start, end).
+type lineSections struct {
+	fantizi  [2]int
+	jiantizi [2]int
+	pinyin   [2]int
+	// gloss runs from after the first "/" to the last one, senses are
+	// separated by "/"
+	gloss      [2]int
+	glossCount int
+	version    FormatVersion
+}
+
 // Traditional Simplified [[pin1yin1]] /gloss; gloss; .../gloss; gloss; .../
+//
+// The line is scanned byte by byte: every delimiter is ASCII and UTF-8 never
+// uses ASCII bytes inside a multi-byte rune. Headwords, pinyin and senses are
+// substrings of line, so a parsed entry costs three allocations: the gloss,
+// the words and their syllables. Invalid UTF-8 is kept as is.
 func parseLine(pinyinVals map[string]bool, line string) (Ci, error) {
+	sec, err := scanLine(line)
+	if err != nil {
+		return Ci{}, err
+	}
+	return parseSections(pinyinVals, line, sec)
+}
+
+// scanLine finds the sections of line and the pinyin version, without
+// parsing the pinyin.
+func scanLine(line string) (lineSections, error) {
+	var sec lineSections
+
 	if strings.HasPrefix(line, "#") {
-		return Ci{}, errors.New("comment line")
+		return sec, errors.New("comment line")
 	}
 
 	if strings.TrimSpace(line) == "" {
-		return Ci{}, errors.New("empty line")
+		return sec, errors.New("empty line")
 	}
 
-	traditionalDelimit := " "
-	simplifiedDelimit := " "
-	pinyinStart := "["
-	pinyinEnd := "]"
-
-	glossStart := "/"
-
-	var fantizi string
-	var jiantizi string
-	var pinyin string
-	var gloss []string
+	const traditionalDelimit = ' '
+	const simplifiedDelimit = ' '
+	const pinyinStart = '['
+	const pinyinEnd = ']'
+	const glossDelimit = '/'
 
-	var builder strings.Builder
 	currentSection := section_traditional
+	start := 0
 
-	pyVersion := V1
 	pyOpenBracketCount := 0
 	pyCloseBracketCount := 0
 
-	lineRunes := make([]rune, 0, len(line))
+	for i := 0; i < len(line); i++ {
+		c := line[i]
 
-	for _, r := range line {
-		lineRunes = append(lineRunes, r)
-	}
-
-	for i, r := range lineRunes {
-
-		if currentSection == section_traditional {
-
-			if string(r) == pinyinStart {
-				return Ci{}, fmt.Errorf("found pinyin section before completing traditional section. Line: %s", line)
+		switch currentSection {
+		case section_traditional:
+			if c == pinyinStart {
+				return sec, fmt.Errorf("found pinyin section before completing traditional section. Line: %s", line)
 			}
 
-			if string(r) == traditionalDelimit {
-				fantizi = builder.String()
-				builder.Reset()
+			if c == traditionalDelimit {
+				sec.fantizi = [2]int{start, i}
+				start = i + 1
 				currentSection = section_simplified
-			} else {
-				builder.WriteRune(r)
 			}
 
-		} else if currentSection == section_simplified {
-
-			if string(r) == pinyinStart {
-				return Ci{}, fmt.Errorf("found pinyin section before completing simplified section. Line: %s", line)
+		case section_simplified:
+			if c == pinyinStart {
+				return sec, fmt.Errorf("found pinyin section before completing simplified section. Line: %s", line)
 			}
 
-			if string(r) == simplifiedDelimit {
-				jiantizi = builder.String()
-				builder.Reset()
+			if c == simplifiedDelimit {
+				sec.jiantizi = [2]int{start, i}
 				currentSection = section_transition_pinyin
-			} else {
-				builder.WriteRune(r)
 			}
 
-		} else if currentSection == section_transition_pinyin {
-
-			if string(r) == glossStart {
-				return Ci{}, fmt.Errorf("found gloss section before pinyin section. Line: %s", line)
+		case section_transition_pinyin:
+			if c == glossDelimit {
+				return sec, fmt.Errorf("found gloss section before pinyin section. Line: %s", line)
 			}
 
-			if string(r) == pinyinStart {
+			if c == pinyinStart {
 				pyOpenBracketCount++
-			}
-
-			if next, ok := tryPeak(lineRunes, i+1); ok && string(r) == pinyinStart {
-				if string(next) != pinyinStart {
+				if i+1 < len(line) && line[i+1] != pinyinStart {
+					start = i + 1
 					currentSection = section_pinyin
 				}
 			}
 
-		} else if currentSection == section_pinyin {
-			if string(r) == pinyinEnd {
+		case section_pinyin:
+			if c == pinyinEnd {
 				pyCloseBracketCount++
-				pinyin = builder.String()
-				builder.Reset()
+				sec.pinyin = [2]int{start, i}
 				currentSection = section_transition_gloss
-			} else {
-				builder.WriteRune(r)
 			}
-		} else if currentSection == section_transition_gloss {
-			if string(r) == pinyinEnd || string(r) == " " {
-				if string(r) == pinyinEnd {
-					pyCloseBracketCount++
-				}
-				//nothing
-			} else if string(r) == glossStart {
+
+		case section_transition_gloss:
+			if c == pinyinEnd {
+				pyCloseBracketCount++
+			} else if c == glossDelimit {
+				sec.gloss = [2]int{i + 1, i + 1}
 				currentSection = section_gloss
-			} else {
-				return Ci{}, fmt.Errorf("failed to read gloss for line (%s)", line)
+			} else if c != ' ' {
+				return sec, fmt.Errorf("failed to read gloss for line (%s)", line)
 			}
-		} else if currentSection == section_gloss {
-			if string(r) == "/" {
-				gloss = append(gloss, builder.String())
-				builder.Reset()
-			} else {
-				builder.WriteRune(r)
+
+		case section_gloss:
+			if c == glossDelimit {
+				sec.gloss[1] = i
+				sec.glossCount++
 			}
 		}
 	}
 
 	if pyOpenBracketCount != pyCloseBracketCount {
-		return Ci{}, fmt.Errorf("malformed pinyin (cannot determine version) (%d %d). Line: %s", pyOpenBracketCount, pyCloseBracketCount, line)
+		return sec, fmt.Errorf("malformed pinyin (cannot determine version) (%d %d). Line: %s", pyOpenBracketCount, pyCloseBracketCount, line)
 	}
 
 	switch pyOpenBracketCount {
 	case 1:
-		pyVersion = V1
+		sec.version = V1
 	case 2:
-		pyVersion = V2
+		sec.version = V2
 	default:
-		return Ci{}, fmt.Errorf("malformed pinyin (unrecognized version). Line: %s", line)
+		return sec, fmt.Errorf("malformed pinyin (unrecognized version). Line: %s", line)
 	}
 
+	return sec, nil
+}
+
+// parseSections parses and checks the sections of line found by scanLine.
+func parseSections(pinyinVals map[string]bool, line string, sec lineSections) (Ci, error) {
+	fantizi := line[sec.fantizi[0]:sec.fantizi[1]]
+	jiantizi := line[sec.jiantizi[0]:sec.jiantizi[1]]
+	pinyin := line[sec.pinyin[0]:sec.pinyin[1]]
+
 	var py []PinyinV2
 	var err error
-	if pyVersion == V1 {
+	if sec.version == V1 {
 		py, err = pinyinV1StrToPinyin(pinyin)
 	} else {
 		py, err = pinyinV2StrToPinyin(pinyin)
@@ -522,31 +538,34 @@ func parseLine(pinyinVals map[string]bool, line string) (Ci, error) {
 		return Ci{}, fmt.Errorf("no pinyin found. Line: %s", line)
 	}
 
-	if len(gloss) == 0 {
+	if sec.glossCount == 0 {
 		return Ci{}, fmt.Errorf("no gloss found. Line: %s", line)
 	}
 
-	for _, v := range pinyin {
-		if string(norm.NFD.Bytes([]byte(string(v)))) != string(v) {
-			// Really struggling to detect this
-			return Ci{}, fmt.Errorf("malformed pinyin - no diacritics. Line: %s", line)
-		}
+	if hasDecomposableRune(pinyin) {
+		// Really struggling to detect this
+		return Ci{}, fmt.Errorf("malformed pinyin - no diacritics. Line: %s", line)
 	}
 
 	for _, v := range py {
 		for _, p := range v.Word {
-			if p.Type == Normal && !pinyinVals[strings.ToLower(p.Sound)] {
+			if p.Type == Normal && !isPinyinSyllable(pinyinVals, p.Sound) {
 				return Ci{}, fmt.Errorf("malformed pinyin - unrecognized pinyin value (check for ambiguity). Line: %s", line)
 			}
 		}
 	}
 
+	gloss := make([]string, 0, sec.glossCount)
+	for sense := range strings.SplitSeq(line[sec.gloss[0]:sec.gloss[1]], "/") {
+		gloss = append(gloss, sense)
+	}
+
 	return Ci{
 		Fantizi:       fantizi,
 		Jiantizi:      jiantizi,
 		Pinyin:        py,
 		PinyinRaw:     pinyin,
 		Gloss:         gloss,
-		FormatVersion: pyVersion,
+		FormatVersion: sec.version,
 	}, nil
 }
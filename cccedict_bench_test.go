@@ -1,10 +1,19 @@
 package cccedictparser
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+)
 
+// benchLine is a long V2 entry, benchLineV1 a typical one.
 const benchLine = "分久必合，合久必分 分久必合，合久必分 [[fen1jiu3-bi4he2, he2jiu3-bi4fen1]] /lit. that which is long divided must unify, and that which is long unified must divide (proverb, from Romance of the Three Kingdoms 三國演義|三国演义[San1guo2 Yan3yi4])/fig. things are constantly changing/"
+const benchLineV1 = "中國 中国 [Zhong1 guo2] /China/"
 
 func BenchmarkParseLine(b *testing.B) {
+	b.ReportAllocs()
 	for b.Loop() {
 		ParseLine(benchLine)
 	}
@@ -12,7 +21,111 @@ func BenchmarkParseLine(b *testing.B) {
 
 func BenchmarkLineParserParseLine(b *testing.B) {
 	lp := NewLineParser()
+	b.ReportAllocs()
 	for b.Loop() {
 		lp.ParseLine(benchLine)
 	}
 }
+
+func BenchmarkLineParserParseLineV1(b *testing.B) {
+	lp := NewLineParser()
+	b.ReportAllocs()
+	for b.Loop() {
+		lp.ParseLine(benchLineV1)
+	}
+}
+
+// benchDictionary is about the size of the full cc-cedict file.
+func benchDictionary(b *testing.B) []byte {
+	data, err := os.ReadFile(sampleDictionary)
+	if err != nil {
+		b.Fatal(err)
+	}
+	lines := bytes.Count(data, []byte("\n"))
+	return bytes.Repeat(data, 120000/lines)
+}
+
+func BenchmarkReadDictionary(b *testing.B) {
+	data := benchDictionary(b)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		ReadDictionary(bytes.NewReader(data))
+	}
+}
+
+func BenchmarkLoadDictionary(b *testing.B) {
+	data := benchDictionary(b)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for b.Loop() {
+				LoadDictionary(context.Background(), bytes.NewReader(data), LoadOptions{Workers: workers})
+			}
+		})
+	}
+}
+
+func BenchmarkNewViews(b *testing.B) {
+	data := benchDictionary(b)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		NewViews(data)
+	}
+}
+
+func benchSnapshot(b *testing.B) []byte {
+	entries, err := ReadDictionary(bytes.NewReader(benchDictionary(b)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, entries); err != nil {
+		b.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkReadSnapshot(b *testing.B) {
+	data := benchSnapshot(b)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		ReadSnapshot(bytes.NewReader(data))
+	}
+}
+
+func BenchmarkNewSnapshot(b *testing.B) {
+	data := benchSnapshot(b)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		NewSnapshot(data)
+	}
+}
+
+func BenchmarkDiskIndexLookup(b *testing.B) {
+	entries, err := ReadDictionary(bytes.NewReader(benchDictionary(b)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteDiskIndex(&buf, entries, DiskIndexOptions{}); err != nil {
+		b.Fatal(err)
+	}
+	d, err := NewDiskIndex(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// the bench dictionary repeats the sample, so a hit reads every copy
+	for _, word := range []string{"中国", "不存在"} {
+		b.Run(word, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				d.Lookup(word)
+			}
+		})
+	}
+}
@@ -26,6 +26,8 @@ func TestParseLine(t *testing.T) {
 		{Name: "parseLine_PinyinV1Matches", Test: parseLine_PinyinV1Matches},
 		{Name: "parseLine_PinyinV2Matches", Test: parseLine_PinyinV2Matches},
 		{Name: "parseLine_FullMatches", Test: parseLine_FullMatches},
+		{Name: "formatLine_RoundTrip", Test: formatLine_RoundTrip},
+		{Name: "parseLine_Allocs", Test: parseLine_Allocs},
 	}
 
 	for _, v := range tests {
@@ -33,6 +35,24 @@ func TestParseLine(t *testing.T) {
 	}
 }
 
+// parse_line_max_allocs is the allocation target of ParseLine: the gloss, the
+// words and the syllables of an entry.
+const parse_line_max_allocs = 3
+
+func parseLine_Allocs(t *testing.T) {
+	lp := NewLineParser()
+	for _, line := range []string{benchLine, benchLineV1} {
+		allocs := testing.AllocsPerRun(100, func() {
+			if _, err := lp.ParseLine(line); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs > parse_line_max_allocs {
+			t.Errorf("%s: expected at most %d allocations, got %.0f", line, parse_line_max_allocs, allocs)
+		}
+	}
+}
+
 func parseLine_PinyinInGloss(t *testing.T) {
 	line := "㗂 㗂 [sheng3] /variant of 省[sheng3]/tight-lipped/to examine/to watch/to scour (esp. Cantonese)/"
 	expected := "variant of 省[sheng3]"
@@ -938,3 +958,23 @@ func ciEq(a Ci, b Ci) bool {
 	}
 	return true
 }
+
+func formatLine_RoundTrip(t *testing.T) {
+	cases := []string{
+		"損人不利己 损人不利己 [sun3 ren2 bu4 li4 ji3] /to harm others without benefiting oneself (idiom)/",
+		"皮實 皮实 [[pi2shi5]] /(of things) durable/(of people) sturdy; tough/",
+		"㗂 㗂 [sheng3] /variant of 省[sheng3]/tight-lipped/to examine/to watch/to scour (esp. Cantonese)/",
+	}
+
+	for _, v := range cases {
+		parsed, err := ParseLine(v)
+		if err != nil {
+			t.Errorf("error: %s. Line %s", err.Error(), v)
+			continue
+		}
+
+		if out := FormatLine(parsed); out != v {
+			t.Errorf("expected %s, got %s", v, out)
+		}
+	}
+}
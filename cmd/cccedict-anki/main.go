@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	cccedictparser "github.com/xDestx/cc-cedict-reader"
+)
+
+const help_text = "Format: <cmd> --dict <cc-cedict file> [--words <file>] [filters] [--deck name] [--notetype name] [--tags a,b]\nEx: cccedict-anki --dict cedict_ts.u8 --words hsk1.txt > hsk1.txt\nEx: cccedict-anki --dict cedict_ts.u8 --tag idiom --match '????' > chengyu.txt\n"
+
+func main() {
+	dictPath := flag.String("dict", "", "cc-cedict dictionary file (required)")
+	wordsPath := flag.String("words", "", "word list, one headword per line, - for stdin")
+	match := flag.String("match", "", "keep headwords matching a pattern (? one character, * any)")
+	tag := flag.String("tag", "", "keep entries with a usage tag, e.g. idiom, coll")
+	english := flag.String("english", "", "keep entries whose gloss contains these English words")
+	tones := flag.String("tones", "", "keep entries with a tone pattern, e.g. 3-3 or 4-?")
+	deck := flag.String("deck", "", "Anki deck to import into")
+	noteType := flag.String("notetype", "", "Anki note type to import as")
+	tags := flag.String("tags", "", "comma separated tags added to every note")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), help_text)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *dictPath == "" || flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	idx, failed := loadIndex(*dictPath)
+
+	f, err := newFilter(idx, *match, *tag, *english, *tones)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var entries []cccedictparser.Ci
+	if *wordsPath != "" {
+		var missing int
+		entries, missing = readWordsFile(*wordsPath, idx, f)
+		failed += missing
+	} else {
+		for _, ci := range idx.Entries() {
+			if f.keep(ci) {
+				entries = append(entries, ci)
+			}
+		}
+	}
+
+	opts := cccedictparser.AnkiOptions{Deck: *deck, NoteType: *noteType}
+	if *tags != "" {
+		opts.Tags = strings.Split(*tags, ",")
+	}
+
+	if err := cccedictparser.WriteAnki(os.Stdout, entries, opts); err != nil {
+		log.Fatal(err)
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d line(s) failed\n", failed)
+		os.Exit(1)
+	}
+}
+
+// loadIndex reads the dictionary, reporting lines which fail to parse.
+func loadIndex(path string) (*cccedictparser.Index, int) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	entries, err := cccedictparser.ReadDictionary(f)
+	failed := 0
+	if err != nil {
+		var joined interface{ Unwrap() []error }
+		errs := []error{err}
+		if errors.As(err, &joined) {
+			errs = joined.Unwrap()
+		}
+		for _, e := range errs {
+			var lineErr *cccedictparser.LineError
+			if !errors.As(e, &lineErr) {
+				log.Fatal(e)
+			}
+			failed++
+			fmt.Fprintf(os.Stderr, "%s: line %d: %s\n", path, lineErr.Line, lineErr.Err.Error())
+		}
+	}
+
+	return cccedictparser.NewIndex(entries), failed
+}
+
+// filter keeps entries in every set and with the tag, if set.
+type filter struct {
+	sets []map[string]bool
+	tag  string
+}
+
+// newFilter builds the filter of the command line flags, an empty string
+// leaving a flag unset.
+func newFilter(idx *cccedictparser.Index, match, tag, english, tones string) (filter, error) {
+	f := filter{tag: tag}
+	if match != "" {
+		f.sets = append(f.sets, keySet(idx.Match(match)))
+	}
+	if english != "" {
+		f.sets = append(f.sets, keySet(idx.SearchEnglish(english)))
+	}
+	if tones != "" {
+		tp, err := cccedictparser.ParseTonePattern(tones)
+		if err != nil {
+			return filter{}, err
+		}
+		f.sets = append(f.sets, keySet(idx.ByTonePattern(tp)))
+	}
+	return f, nil
+}
+
+func keySet(entries []cccedictparser.Ci) map[string]bool {
+	set := make(map[string]bool, len(entries))
+	for _, ci := range entries {
+		set[cccedictparser.FormatLine(ci)] = true
+	}
+	return set
+}
+
+func (f filter) keep(ci cccedictparser.Ci) bool {
+	if f.tag != "" {
+		found := false
+		for _, t := range ci.Tags() {
+			found = found || t == f.tag
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.sets) == 0 {
+		return true
+	}
+	key := cccedictparser.FormatLine(ci)
+	for _, s := range f.sets {
+		if !s[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// readWordsFile reads the word list at path, - for stdin.
+func readWordsFile(path string, idx *cccedictparser.Index, f filter) ([]cccedictparser.Ci, int) {
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	entries, missing, err := readWords(input, path, idx, f, os.Stderr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return entries, missing
+}
+
+// readWords returns the entries of the words of a word list, in list order.
+// Only the first tab or space separated field of a line is read, so lists
+// exported with pinyin or definitions work too. Words without entries are
+// counted and reported to errOut.
+func readWords(r io.Reader, name string, idx *cccedictparser.Index, f filter, errOut io.Writer) ([]cccedictparser.Ci, int, error) {
+	var entries []cccedictparser.Ci
+	seen := make(map[string]bool)
+	missing := 0
+	lineNo := 0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNo++
+		fields := strings.Fields(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "//") {
+			continue
+		}
+
+		found := idx.Lookup(fields[0])
+		if len(found) == 0 {
+			missing++
+			fmt.Fprintf(errOut, "%s: line %d: no entry for %s\n", name, lineNo, fields[0])
+			continue
+		}
+
+		for _, ci := range found {
+			key := cccedictparser.FormatLine(ci)
+			if !seen[key] && f.keep(ci) {
+				seen[key] = true
+				entries = append(entries, ci)
+			}
+		}
+	}
+
+	return entries, missing, scanner.Err()
+}
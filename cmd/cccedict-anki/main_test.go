@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	cccedictparser "github.com/xDestx/cc-cedict-reader"
+)
+
+func loadSampleIndex(t *testing.T) *cccedictparser.Index {
+	t.Helper()
+	f, err := os.Open("../../testdata/sample.u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	entries, err := cccedictparser.ReadDictionary(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cccedictparser.NewIndex(entries)
+}
+
+func headwords(entries []cccedictparser.Ci) string {
+	out := make([]string, 0, len(entries))
+	for _, ci := range entries {
+		out = append(out, ci.Jiantizi)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestReadWords(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	cases := []struct {
+		name     string
+		list     string
+		expected string
+		missing  int
+		errOut   string
+	}{
+		{name: "one per line", list: "中国\n学习\n", expected: "中国,学习"},
+		{name: "blank lines and comments", list: "\n# HSK 1\n中国\n  \n// more\n学习\n", expected: "中国,学习"},
+		{name: "extra fields", list: "中国\tzhong1 guo2\tChina\n学习 xue2xi2\n", expected: "中国,学习"},
+		{name: "byte order mark", list: "\ufeff中国\n", expected: "中国"},
+		{name: "traditional", list: "學習\n", expected: "学习"},
+		{name: "duplicates", list: "中国\n中國\n", expected: "中国"},
+		{name: "unknown words", list: "中国\n不存在\n学习\n没有\n", expected: "中国,学习", missing: 2,
+			errOut: "words.txt: line 2: no entry for 不存在\nwords.txt: line 4: no entry for 没有\n"},
+		{name: "every reading", list: "中\n", expected: "中,中,中"},
+	}
+
+	for _, v := range cases {
+		var errOut bytes.Buffer
+		got, missing, err := readWords(strings.NewReader(v.list), "words.txt", idx, filter{}, &errOut)
+		if err != nil {
+			t.Fatalf("%s: %s", v.name, err.Error())
+		}
+		if headwords(got) != v.expected || missing != v.missing || errOut.String() != v.errOut {
+			t.Errorf("%s: expected %s with %d missing (%q), got %s with %d (%q)", v.name, v.expected, v.missing, v.errOut, headwords(got), missing, errOut.String())
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	cases := []struct {
+		name     string
+		match    string
+		tag      string
+		english  string
+		tones    string
+		expected string
+	}{
+		{name: "tag", tag: "idiom", expected: "各得其所,见利忘义,画蛇添足"},
+		{name: "tag and match", tag: "idiom", match: "画*", expected: "画蛇添足"},
+		{name: "english", english: "China", expected: "中,中国,西安"},
+		{name: "english and tones", english: "China", tones: "1-2", expected: "中国"},
+		{name: "no match", match: "不存在", expected: ""},
+	}
+
+	for _, v := range cases {
+		f, err := newFilter(idx, v.match, v.tag, v.english, v.tones)
+		if err != nil {
+			t.Fatalf("%s: %s", v.name, err.Error())
+		}
+		var got []cccedictparser.Ci
+		for _, ci := range idx.Entries() {
+			if f.keep(ci) {
+				got = append(got, ci)
+			}
+		}
+		if headwords(got) != v.expected {
+			t.Errorf("%s: expected %s, got %s", v.name, v.expected, headwords(got))
+		}
+	}
+
+	if _, err := newFilter(idx, "", "", "", "9-x"); err == nil {
+		t.Error("expected an error for a bad tone pattern")
+	}
+}
+
+func TestFilterWordList(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	f, err := newFilter(idx, "", "", "", "4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, missing, err := readWords(strings.NewReader("中\n国\n"), "words.txt", idx, f, &bytes.Buffer{})
+	if err != nil || missing != 0 || headwords(got) != "中" {
+		t.Errorf("expected 中 (zhong4) only, got %s with %d missing (%v)", headwords(got), missing, err)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	cccedictparser "github.com/xDestx/cc-cedict-reader"
+)
+
+const help_text = "Format: <cmd> --dict <cc-cedict file> [--addr host:port] [--name database] [--export dir [--dictzip]]\nEx: cccedict-dictd --dict cedict_ts.u8\nEx: dict -h localhost -d cedict 中国\nEx: cccedict-dictd --dict cedict_ts.u8 --export /usr/share/dictd --dictzip\n"
+
+func main() {
+	dictPath := flag.String("dict", "", "cc-cedict dictionary file (required)")
+	addr := flag.String("addr", ":2628", "address to listen on")
+	name := flag.String("name", "cedict", "database name")
+	short := flag.String("short", "CC-CEDICT Chinese-English dictionary", "database description shown by SHOW DB")
+	export := flag.String("export", "", "write the database as dictd files into this directory instead of serving")
+	dictzip := flag.Bool("dictzip", false, "compress the exported .dict file with dictzip")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), help_text)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *dictPath == "" || flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*dictPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	entries, err := cccedictparser.ReadDictionary(f)
+	f.Close()
+	if err != nil {
+		// entries which failed to parse are left out
+		fmt.Fprintln(os.Stderr, err.Error())
+	}
+
+	if *export != "" {
+		opts := cccedictparser.DictdOptions{Short: *short, URL: "https://www.mdbg.net/chinese/dictionary?page=cc-cedict", Dictzip: *dictzip}
+		if err := cccedictparser.WriteDictd(*export, *name, entries, opts); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	s := newServer(*name, *short, cccedictparser.NewIndex(entries))
+	log.Printf("serving %d entries as %s on %s", len(entries), *name, *addr)
+	log.Fatal(s.listen(*addr))
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	cccedictparser "github.com/xDestx/cc-cedict-reader"
+)
+
+// max matches returned by the prefix strategy
+const max_prefix_matches = 200
+
+// idle time before a client is disconnected
+const idle_timeout = 10 * time.Minute
+
+// longest command line accepted, RFC 2229 allows 1024 bytes with the CRLF
+const max_line_length = 1024
+
+// clients served at once, others are answered 420
+const max_connections = 64
+
+var strategies = [][2]string{
+	{"exact", "Match headwords exactly"},
+	{"prefix", "Match headwords and pinyin starting with the word"},
+	{"pinyin", "Match the pinyin, with or without tones (zhong1guo2, zhongguo, zhōngguó)"},
+}
+
+// default_strategy is used for the "." strategy
+const default_strategy = "prefix"
+
+// server answers DICT protocol (RFC 2229) clients from one database.
+type server struct {
+	name  string
+	short string
+	info  string
+	host  string
+	idx   *cccedictparser.Index
+	trie  *cccedictparser.Trie
+}
+
+func newServer(name string, short string, idx *cccedictparser.Index) *server {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	return &server{
+		name:  name,
+		short: short,
+		info:  fmt.Sprintf("%s\n\n%d entries.\nSee https://www.mdbg.net/chinese/dictionary?page=cc-cedict\n", short, idx.Len()),
+		host:  host,
+		idx:   idx,
+		trie:  cccedictparser.NewTrie(idx.Entries()),
+	}
+}
+
+func (s *server) listen(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	sem := make(chan struct{}, max_connections)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		select {
+		case sem <- struct{}{}:
+			go func() {
+				defer func() { <-sem }()
+				s.serve(conn)
+			}()
+		default:
+			conn.SetDeadline(time.Now().Add(time.Second))
+			io.WriteString(conn, "420 server temporarily unavailable\r\n")
+			conn.Close()
+		}
+	}
+}
+
+// errLineTooLong is returned once a client sends max_line_length bytes
+// without a newline.
+var errLineTooLong = errors.New("line too long")
+
+// lineLimitReader passes on complete lines only, and fails once it holds
+// max_line_length bytes without a newline. It sits under the bufio.Reader of
+// the session, which therefore never sees part of a long line: lines before
+// it are read as usual, then ReadLine returns errLineTooLong.
+type lineLimitReader struct {
+	r io.Reader
+	// buf holds what was read past the last newline returned.
+	buf []byte
+	err error
+}
+
+func (l *lineLimitReader) Read(p []byte) (int, error) {
+	if l.buf == nil {
+		l.buf = make([]byte, 0, max_line_length)
+	}
+	for {
+		if i := bytes.LastIndexByte(l.buf, '\n'); i >= 0 {
+			n := copy(p, l.buf[:i+1])
+			l.buf = l.buf[:copy(l.buf, l.buf[n:])]
+			return n, nil
+		}
+		if l.err != nil {
+			return 0, l.err
+		}
+		if len(l.buf) == cap(l.buf) {
+			return 0, errLineTooLong
+		}
+
+		n, err := l.r.Read(l.buf[len(l.buf):cap(l.buf)])
+		l.buf = l.buf[:len(l.buf)+n]
+		l.err = err
+	}
+}
+
+// session is the state of one client connection.
+type session struct {
+	*server
+	w    *textproto.Writer
+	mime bool
+}
+
+func (s *server) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := textproto.NewReader(bufio.NewReader(&lineLimitReader{r: conn}))
+	sess := &session{server: s, w: textproto.NewWriter(bufio.NewWriter(conn))}
+
+	conn.SetDeadline(time.Now().Add(idle_timeout))
+	if sess.w.PrintfLine("220 %s cccedict-dictd <mime> <%d.%d@%s>", s.host, os.Getpid(), time.Now().UnixNano(), s.host) != nil {
+		return
+	}
+
+	for {
+		line, err := r.ReadLine()
+		if errors.Is(err, errLineTooLong) {
+			sess.w.PrintfLine("500 line too long")
+			return
+		}
+		if err != nil {
+			return
+		}
+		conn.SetDeadline(time.Now().Add(idle_timeout))
+
+		quit, err := sess.handle(line)
+		if err != nil || quit {
+			return
+		}
+	}
+}
+
+// splitCommand splits a command line into words. Words are separated by
+// spaces and may be quoted with " or ', with \ escaping the next character.
+func splitCommand(line string) ([]string, error) {
+	var words []string
+	var b strings.Builder
+	inWord := false
+	var quote rune
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("trailing escape")
+			}
+			i++
+			b.WriteRune(runes[i])
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				b.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, b.String())
+				b.Reset()
+				inWord = false
+			}
+		default:
+			b.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unclosed quote")
+	}
+	if inWord {
+		words = append(words, b.String())
+	}
+	return words, nil
+}
+
+// quote writes a word as a quoted string of the protocol.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// handle answers one command line and reports whether the client quit.
+func (s *session) handle(line string) (bool, error) {
+	words, err := splitCommand(line)
+	if err != nil {
+		return false, s.w.PrintfLine("501 syntax error, illegal parameters")
+	}
+	if len(words) == 0 {
+		return false, s.w.PrintfLine("500 syntax error, command not recognized")
+	}
+
+	args := words[1:]
+	switch strings.ToUpper(words[0]) {
+	case "DEFINE":
+		if len(args) != 2 {
+			return false, s.w.PrintfLine("501 syntax error, illegal parameters")
+		}
+		return false, s.define(args[0], args[1])
+	case "MATCH":
+		if len(args) != 3 {
+			return false, s.w.PrintfLine("501 syntax error, illegal parameters")
+		}
+		return false, s.match(args[0], args[1], args[2])
+	case "SHOW":
+		if len(args) == 0 {
+			return false, s.w.PrintfLine("501 syntax error, illegal parameters")
+		}
+		return false, s.show(strings.ToUpper(args[0]), args[1:])
+	case "CLIENT":
+		return false, s.w.PrintfLine("250 ok")
+	case "OPTION":
+		if len(args) == 1 && strings.ToUpper(args[0]) == "MIME" {
+			s.mime = true
+			return false, s.w.PrintfLine("250 ok - using MIME headers")
+		}
+		return false, s.w.PrintfLine("501 syntax error, illegal parameters")
+	case "STATUS":
+		return false, s.w.PrintfLine("210 status: %d entries", s.idx.Len())
+	case "HELP":
+		return false, s.text("113 help text follows", help_commands)
+	case "AUTH", "SASLAUTH":
+		return false, s.w.PrintfLine("502 command not implemented")
+	case "QUIT":
+		return true, s.w.PrintfLine("221 bye")
+	}
+	return false, s.w.PrintfLine("500 syntax error, command not recognized")
+}
+
+const help_commands = `DEFINE database word         -- look up word in database
+MATCH database strategy word -- match word in database using strategy
+SHOW DB                      -- list all accessible databases
+SHOW STRAT                   -- list available matching strategies
+SHOW INFO database           -- provide information about the database
+SHOW SERVER                  -- provide site-specific information
+OPTION MIME                  -- use MIME headers
+CLIENT info                  -- identify client to server
+STATUS                       -- display timing information
+HELP                         -- display this help information
+QUIT                         -- terminate connection
+`
+
+// text writes a status line then a dot-encoded text block and "250 ok".
+func (s *session) text(status string, body string) error {
+	if err := s.w.PrintfLine("%s", status); err != nil {
+		return err
+	}
+	if err := s.block(body); err != nil {
+		return err
+	}
+	return s.w.PrintfLine("250 ok")
+}
+
+// block writes a dot-encoded text block, with MIME headers if requested.
+func (s *session) block(body string) error {
+	dw := s.w.DotWriter()
+	if s.mime {
+		io.WriteString(dw, "Content-type: text/plain; charset=utf-8\nContent-transfer-encoding: 8bit\n\n")
+	}
+	io.WriteString(dw, body)
+	return dw.Close()
+}
+
+// database reports whether db names the database: its name, "*" (all
+// databases) or "!" (first database with a match).
+func (s *session) database(db string) bool {
+	return db == s.name || db == "*" || db == "!"
+}
+
+// lookup returns the entries defining word: by headword, else by pinyin.
+func (s *session) lookup(word string) []cccedictparser.Ci {
+	if found := s.idx.Lookup(word); len(found) > 0 {
+		return found
+	}
+	return s.lookupPinyin(word)
+}
+
+// lookupPinyin accepts pinyin with tone numbers, tone marks or no tones.
+func (s *session) lookupPinyin(word string) []cccedictparser.Ci {
+	if found := s.idx.LookupPinyin(word); len(found) > 0 {
+		return found
+	}
+	if numbered, err := cccedictparser.NumberedPinyin(word); err == nil {
+		return s.idx.LookupPinyin(numbered)
+	}
+	return nil
+}
+
+func (s *session) define(db string, word string) error {
+	if !s.database(db) {
+		return s.w.PrintfLine("550 invalid database, use \"SHOW DB\" for list of databases")
+	}
+
+	found := s.lookup(word)
+	if len(found) == 0 {
+		return s.w.PrintfLine("552 no match")
+	}
+
+	if err := s.w.PrintfLine("150 %d definitions retrieved", len(found)); err != nil {
+		return err
+	}
+	for _, ci := range found {
+		if err := s.w.PrintfLine("151 %s %s %s", quote(ci.Jiantizi), s.name, quote(s.short)); err != nil {
+			return err
+		}
+		if err := s.block(cccedictparser.DictdArticle(ci)); err != nil {
+			return err
+		}
+	}
+	return s.w.PrintfLine("250 ok")
+}
+
+func (s *session) match(db string, strategy string, word string) error {
+	if !s.database(db) {
+		return s.w.PrintfLine("550 invalid database, use \"SHOW DB\" for list of databases")
+	}
+	if strategy == "." {
+		strategy = default_strategy
+	}
+
+	var found []cccedictparser.Ci
+	switch strategy {
+	case "exact":
+		found = s.idx.Lookup(word)
+	case "prefix":
+		found = s.trie.Prefix(word, max_prefix_matches)
+	case "pinyin":
+		found = s.lookupPinyin(word)
+	default:
+		return s.w.PrintfLine("551 invalid strategy, use \"SHOW STRAT\" for a list of strategies")
+	}
+
+	var words []string
+	for _, ci := range found {
+		if w := matchedHeadword(ci, word); !slices.Contains(words, w) {
+			words = append(words, w)
+		}
+	}
+	if len(words) == 0 {
+		return s.w.PrintfLine("552 no match")
+	}
+
+	var b strings.Builder
+	for _, w := range words {
+		fmt.Fprintf(&b, "%s %s\n", s.name, quote(w))
+	}
+	if err := s.w.PrintfLine("152 %d matches found", len(words)); err != nil {
+		return err
+	}
+	if err := s.block(b.String()); err != nil {
+		return err
+	}
+	return s.w.PrintfLine("250 ok")
+}
+
+// matchedHeadword is the headword listed for a match of word: the
+// traditional one when only it starts with word, else the simplified one.
+func matchedHeadword(ci cccedictparser.Ci, word string) string {
+	if !strings.HasPrefix(ci.Jiantizi, word) && strings.HasPrefix(ci.Fantizi, word) {
+		return ci.Fantizi
+	}
+	return ci.Jiantizi
+}
+
+func (s *session) show(what string, args []string) error {
+	switch what {
+	case "DB", "DATABASES":
+		return s.text("110 1 databases present", fmt.Sprintf("%s %s\n", s.name, quote(s.short)))
+	case "STRAT", "STRATEGIES":
+		var b strings.Builder
+		for _, st := range strategies {
+			fmt.Fprintf(&b, "%s %s\n", st[0], quote(st[1]))
+		}
+		return s.text(fmt.Sprintf("111 %d strategies present", len(strategies)), b.String())
+	case "INFO":
+		if len(args) != 1 {
+			return s.w.PrintfLine("501 syntax error, illegal parameters")
+		}
+		if !s.database(args[0]) {
+			return s.w.PrintfLine("550 invalid database, use \"SHOW DB\" for list of databases")
+		}
+		return s.text("112 database information follows", s.info)
+	case "SERVER":
+		return s.text("114 server information follows", fmt.Sprintf("cccedict-dictd on %s\n%s: %d entries\n", s.host, s.name, s.idx.Len()))
+	}
+	return s.w.PrintfLine("501 syntax error, illegal parameters")
+}
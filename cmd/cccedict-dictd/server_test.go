@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+
+	cccedictparser "github.com/xDestx/cc-cedict-reader"
+)
+
+// dial serves one session over a pipe and returns the client side, after
+// reading the banner.
+func dial(t *testing.T) *textproto.Conn {
+	t.Helper()
+	f, err := os.Open("../../testdata/sample.u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	entries, err := cccedictparser.ReadDictionary(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, conn := net.Pipe()
+	go newServer("cedict", "CC-CEDICT", cccedictparser.NewIndex(entries)).serve(conn)
+
+	c := textproto.NewConn(client)
+	t.Cleanup(func() { c.Close() })
+	if _, _, err := c.ReadCodeLine(220); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// exchange sends a command and returns the status lines and text blocks of
+// the response up to its final status line.
+func exchange(t *testing.T, c *textproto.Conn, cmd string) []string {
+	t.Helper()
+	if err := c.PrintfLine("%s", cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	var out []string
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, line)
+
+		switch line[:3] {
+		case "110", "111", "112", "113", "114", "151", "152":
+			block, err := c.ReadDotLines()
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, strings.Join(block, "\n"))
+		case "150":
+		default:
+			return out
+		}
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	cases := map[string]string{
+		`DEFINE cedict 中国`:               "DEFINE|cedict|中国",
+		`MATCH  *  prefix "zhong guo"`:   "MATCH|*|prefix|zhong guo",
+		`DEFINE cedict 'Xi\'an'`:         "DEFINE|cedict|Xi'an",
+		`CLIENT "a \"quoted\" client"  `: `CLIENT|a "quoted" client`,
+	}
+
+	for line, expected := range cases {
+		words, err := splitCommand(line)
+		if err != nil {
+			t.Errorf("%s: %s", line, err.Error())
+			continue
+		}
+		if got := strings.Join(words, "|"); got != expected {
+			t.Errorf("%s: expected %s, got %s", line, expected, got)
+		}
+	}
+
+	for _, line := range []string{`DEFINE "open`, `DEFINE x\`} {
+		if _, err := splitCommand(line); err == nil {
+			t.Errorf("%s: expected an error", line)
+		}
+	}
+}
+
+func TestDefine(t *testing.T) {
+	c := dial(t)
+
+	got := exchange(t, c, "DEFINE cedict 中國")
+	expected := []string{
+		"150 1 definitions retrieved",
+		`151 "中国" cedict "CC-CEDICT"`,
+		"中国 (中國) [Zhōng guó]\n   1. China",
+		"250 ok",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	if got := exchange(t, c, "DEFINE * zhong"); got[0] != "150 3 definitions retrieved" {
+		t.Errorf("unexpected response to pinyin define %q", got)
+	}
+	if got := exchange(t, c, "DEFINE ! xuéxí"); got[0] != "150 1 definitions retrieved" || !strings.HasPrefix(got[2], "学习 (學習)") {
+		t.Errorf("unexpected response to tone marked define %q", got)
+	}
+	if got := exchange(t, c, "DEFINE cedict 不存在"); got[0] != "552 no match" {
+		t.Errorf("unexpected response %q", got)
+	}
+	if got := exchange(t, c, "DEFINE wordnet 中国"); !strings.HasPrefix(got[0], "550 ") {
+		t.Errorf("unexpected response %q", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	c := dial(t)
+
+	cases := []struct {
+		cmd      string
+		expected string
+	}{
+		{"MATCH cedict exact 學", `cedict "學"`},
+		{"MATCH cedict prefix 大学", "cedict \"大学\"\ncedict \"大学生\""},
+		{"MATCH cedict . 大學", "cedict \"大學\"\ncedict \"大學生\""},
+		{"MATCH cedict prefix laoh", `cedict "老虎"`},
+		{"MATCH cedict pinyin shi4", "cedict \"是\"\ncedict \"事\"\ncedict \"试\""},
+	}
+
+	for _, v := range cases {
+		got := exchange(t, c, v.cmd)
+		if len(got) != 3 || !strings.HasPrefix(got[0], "152 ") || got[1] != v.expected || got[2] != "250 ok" {
+			t.Errorf("%s: expected %q, got %q", v.cmd, v.expected, got)
+		}
+	}
+
+	if got := exchange(t, c, "MATCH cedict soundex zhong"); !strings.HasPrefix(got[0], "551 ") {
+		t.Errorf("unexpected response %q", got)
+	}
+	if got := exchange(t, c, "MATCH cedict exact 不存在"); got[0] != "552 no match" {
+		t.Errorf("unexpected response %q", got)
+	}
+}
+
+func TestSession(t *testing.T) {
+	c := dial(t)
+
+	if got := exchange(t, c, "SHOW DB"); got[0] != "110 1 databases present" || got[1] != `cedict "CC-CEDICT"` {
+		t.Errorf("unexpected response %q", got)
+	}
+	if got := exchange(t, c, "show strat"); got[0] != "111 3 strategies present" {
+		t.Errorf("unexpected response %q", got)
+	}
+	if got := exchange(t, c, "SHOW INFO cedict"); got[0] != "112 database information follows" {
+		t.Errorf("unexpected response %q", got)
+	}
+	if got := exchange(t, c, "CLIENT test"); got[0] != "250 ok" {
+		t.Errorf("unexpected response %q", got)
+	}
+	if got := exchange(t, c, "OPTION MIME"); !strings.HasPrefix(got[0], "250 ") {
+		t.Errorf("unexpected response %q", got)
+	}
+	if got := exchange(t, c, "DEFINE cedict 好"); !strings.HasPrefix(got[2], "Content-type: text/plain; charset=utf-8\n") {
+		t.Errorf("expected MIME headers, got %q", got)
+	}
+	if got := exchange(t, c, "FOO"); !strings.HasPrefix(got[0], "500 ") {
+		t.Errorf("unexpected response %q", got)
+	}
+	if got := exchange(t, c, "QUIT"); got[0] != "221 bye" {
+		t.Errorf("unexpected response %q", got)
+	}
+
+	// the server closes the connection after QUIT
+	if _, err := bufio.NewReader(c.R).ReadByte(); err == nil {
+		t.Errorf("expected the connection to be closed")
+	}
+}
+
+func TestLongLine(t *testing.T) {
+	c := dial(t)
+
+	// the longest line allowed, with its CRLF
+	longest := "CLIENT " + strings.Repeat("x", max_line_length-len("CLIENT ")-2)
+	if got := exchange(t, c, longest); got[0] != "250 ok" {
+		t.Errorf("unexpected response %q", got)
+	}
+
+	// the pipe blocks the writer until the server reads everything, which it
+	// stops doing after max_line_length bytes. The command before the long
+	// line in the same write is still answered.
+	go c.PrintfLine("CLIENT a\r\nCLIENT %s", strings.Repeat("x", 4*max_line_length))
+
+	for _, want := range []string{"250 ok", "500 line too long"} {
+		line, err := c.ReadLine()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != want {
+			t.Errorf("got %q, expected %q", line, want)
+		}
+	}
+	if _, err := bufio.NewReader(c.R).ReadByte(); err == nil {
+		t.Errorf("expected the connection to be closed")
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	cccedictparser "github.com/xDestx/cc-cedict-reader"
+)
+
+// entryWriter writes parsed entries in one output format.
+type entryWriter interface {
+	// Comment receives comment and blank lines of the input.
+	Comment(line string) error
+	Write(ci cccedictparser.Ci) error
+	// Close finishes the output, it does not close the underlying writer.
+	Close() error
+}
+
+var formats = map[string]func(w io.Writer) entryWriter{
+	"text":      func(w io.Writer) entryWriter { return &textWriter{w: w} },
+	"json":      func(w io.Writer) entryWriter { return &jsonWriter{w: w} },
+	"ndjson":    func(w io.Writer) entryWriter { return &ndjsonWriter{enc: cccedictparser.NewNDJSONEncoder(w)} },
+	"tsv":       func(w io.Writer) entryWriter { return newCSVWriter(w, '\t') },
+	"csv":       func(w io.Writer) entryWriter { return newCSVWriter(w, ',') },
+	"cedict":    func(w io.Writer) entryWriter { return &cedictWriter{w: w} },
+	"snapshot":  func(w io.Writer) entryWriter { return &snapshotWriter{w: w} },
+	"diskindex": func(w io.Writer) entryWriter { return &diskIndexWriter{w: w} },
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(formats))
+	for k := range formats {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newEntryWriter(format string, w io.Writer) (entryWriter, error) {
+	f, ok := formats[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown format (%s), expected one of %s", format, strings.Join(formatNames(), ", "))
+	}
+	return f(w), nil
+}
+
+// textWriter prints Ci.String(), one entry per line.
+type textWriter struct {
+	w io.Writer
+}
+
+func (t *textWriter) Comment(line string) error {
+	return nil
+}
+
+func (t *textWriter) Write(ci cccedictparser.Ci) error {
+	_, err := io.WriteString(t.w, ci.String()+"\n")
+	return err
+}
+
+func (t *textWriter) Close() error {
+	return nil
+}
+
+// jsonWriter streams a single JSON array.
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonWriter) Comment(line string) error {
+	return nil
+}
+
+func (j *jsonWriter) Write(ci cccedictparser.Ci) error {
+	b, err := json.Marshal(ci)
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n"
+	if j.count == 0 {
+		sep = "[\n"
+	}
+	j.count++
+
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(b)
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	end := "\n]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+type ndjsonWriter struct {
+	enc *cccedictparser.NDJSONEncoder
+}
+
+func (n *ndjsonWriter) Comment(line string) error {
+	return nil
+}
+
+func (n *ndjsonWriter) Write(ci cccedictparser.Ci) error {
+	return n.enc.Encode(ci)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// csvWriter writes a header row then cccedictparser.DefaultColumns:
+// traditional, simplified, pinyin and the gloss joined by "/".
+type csvWriter struct {
+	w *cccedictparser.TableWriter
+}
+
+func newCSVWriter(w io.Writer, comma rune) *csvWriter {
+	return &csvWriter{w: cccedictparser.NewTableWriter(w, cccedictparser.TableOptions{Comma: comma})}
+}
+
+func (c *csvWriter) Comment(line string) error {
+	return nil
+}
+
+func (c *csvWriter) Write(ci cccedictparser.Ci) error {
+	return c.w.Write(ci)
+}
+
+func (c *csvWriter) Close() error {
+	return c.w.Flush()
+}
+
+// cedictWriter re-serializes entries as canonical cc-cedict lines and keeps
+// the comments of the input.
+type cedictWriter struct {
+	w io.Writer
+}
+
+func (c *cedictWriter) Comment(line string) error {
+	_, err := io.WriteString(c.w, line+"\n")
+	return err
+}
+
+func (c *cedictWriter) Write(ci cccedictparser.Ci) error {
+	_, err := io.WriteString(c.w, cccedictparser.FormatLine(ci)+"\n")
+	return err
+}
+
+func (c *cedictWriter) Close() error {
+	return nil
+}
+
+// snapshotWriter collects the entries and writes them as one binary snapshot.
+type snapshotWriter struct {
+	w       io.Writer
+	entries []cccedictparser.Ci
+}
+
+func (s *snapshotWriter) Comment(line string) error {
+	return nil
+}
+
+func (s *snapshotWriter) Write(ci cccedictparser.Ci) error {
+	s.entries = append(s.entries, ci)
+	return nil
+}
+
+func (s *snapshotWriter) Close() error {
+	return cccedictparser.WriteSnapshot(s.w, s.entries)
+}
+
+// diskIndexWriter collects the entries and writes them as a disk index.
+type diskIndexWriter struct {
+	w       io.Writer
+	entries []cccedictparser.Ci
+}
+
+func (d *diskIndexWriter) Comment(line string) error {
+	return nil
+}
+
+func (d *diskIndexWriter) Write(ci cccedictparser.Ci) error {
+	d.entries = append(d.entries, ci)
+	return nil
+}
+
+func (d *diskIndexWriter) Close() error {
+	return cccedictparser.WriteDiskIndex(d.w, d.entries, cccedictparser.DiskIndexOptions{})
+}
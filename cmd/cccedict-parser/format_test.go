@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	cccedictparser "github.com/xDestx/cc-cedict-reader"
+)
+
+// CRLF line endings, as in the released file
+const test_input = "# CC-CEDICT\r\n中國 中国 [Zhong1 guo2] /China/Middle Kingdom/\r\n\r\n好 好 [hao3] /good/\"well\"/\r\nbad line\r\n"
+
+func run(t *testing.T, format string, input string) (string, int, string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	w, err := newEntryWriter(format, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed, err := convert(strings.NewReader(input), w, &errOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.String(), failed, errOut.String()
+}
+
+func TestFormats(t *testing.T) {
+	cases := []struct {
+		format   string
+		expected string
+	}{
+		{"json", "[\n" +
+			`{"headword":{"traditional":"中國","simplified":"中国"},"pinyin":[[{"sound":"Zhong","tone":"first","type":"normal"}],[{"sound":"guo","tone":"second","type":"normal"}]],"pinyin_raw":"Zhong1 guo2","gloss":["China","Middle Kingdom"],"format":"V1"},` + "\n" +
+			`{"headword":{"traditional":"好","simplified":"好"},"pinyin":[[{"sound":"hao","tone":"third","type":"normal"}]],"pinyin_raw":"hao3","gloss":["good","\"well\""],"format":"V1"}` + "\n]\n"},
+		{"ndjson", `{"headword":{"traditional":"中國","simplified":"中国"},"pinyin":[[{"sound":"Zhong","tone":"first","type":"normal"}],[{"sound":"guo","tone":"second","type":"normal"}]],"pinyin_raw":"Zhong1 guo2","gloss":["China","Middle Kingdom"],"format":"V1"}` + "\n" +
+			`{"headword":{"traditional":"好","simplified":"好"},"pinyin":[[{"sound":"hao","tone":"third","type":"normal"}]],"pinyin_raw":"hao3","gloss":["good","\"well\""],"format":"V1"}` + "\n"},
+		{"tsv", "traditional\tsimplified\tpinyin\tgloss\n" +
+			"中國\t中国\tZhong1 guo2\tChina/Middle Kingdom\n" +
+			"好\t好\thao3\t\"good/\"\"well\"\"\"\n"},
+		{"csv", "traditional,simplified,pinyin,gloss\n" +
+			"中國,中国,Zhong1 guo2,China/Middle Kingdom\n" +
+			"好,好,hao3,\"good/\"\"well\"\"\"\n"},
+		{"cedict", "# CC-CEDICT\n" +
+			"中國 中国 [Zhong1 guo2] /China/Middle Kingdom/\n" +
+			"\n" +
+			"好 好 [hao3] /good/\"well\"/\n"},
+	}
+
+	for _, v := range cases {
+		got, failed, errOut := run(t, v.format, test_input)
+		if got != v.expected {
+			t.Errorf("%s: expected %q, got %q", v.format, v.expected, got)
+		}
+		if failed != 1 || errOut != "line 5: malformed pinyin (unrecognized version). Line: bad line\n" {
+			t.Errorf("%s: expected line 5 to fail, got %d failures: %q", v.format, failed, errOut)
+		}
+	}
+}
+
+func TestFormatsEmptyInput(t *testing.T) {
+	if got, _, _ := run(t, "json", "# only a comment\n"); got != "[]\n" {
+		t.Errorf("expected an empty JSON array, got %q", got)
+	}
+	if got, _, _ := run(t, "cedict", "# only a comment\r\n"); got != "# only a comment\n" {
+		t.Errorf("expected the comment without its CR, got %q", got)
+	}
+}
+
+func TestBinaryFormats(t *testing.T) {
+	out, _, _ := run(t, "snapshot", test_input)
+	entries, err := cccedictparser.ReadSnapshot(strings.NewReader(out))
+	if err != nil || len(entries) != 2 || entries[1].Gloss[1] != `"well"` {
+		t.Errorf("expected the two entries back from the snapshot, got %v (%v)", entries, err)
+	}
+
+	out, _, _ = run(t, "diskindex", test_input)
+	d, err := cccedictparser.NewDiskIndex(strings.NewReader(out), int64(len(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, err := d.Lookup("中国"); err != nil || len(found) != 1 || found[0].Gloss[1] != "Middle Kingdom" {
+		t.Errorf("expected 中国 from the disk index, got %v (%v)", found, err)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := newEntryWriter("yaml", &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -2,49 +2,98 @@ package main
 
 import (
 	"bufio"
+	"flag"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"strings"
 
 	cccedictparser "github.com/xDestx/cc-cedict-reader"
 )
 
-const help_text = "Format: <cmd> <optional file path>\nEx: cccedict-parser\nEx: cccedict-parser path/to/my/file\n"
+const help_text = "Format: <cmd> [--format text|json|ndjson|tsv|csv|cedict|snapshot|diskindex] <optional file path>\nEx: cccedict-parser\nEx: cccedict-parser path/to/my/file\nEx: cccedict-parser --format ndjson path/to/my/file\n"
 
 func main() {
+	format := flag.String("format", "text", "output format: "+strings.Join(formatNames(), ", "))
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), help_text)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
 	var input io.Reader
-	if len(os.Args) == 2 {
+	if flag.NArg() == 1 {
 		// file
-		f, err := os.Open(os.Args[1])
+		f, err := os.Open(flag.Arg(0))
 		if err != nil {
 			log.Fatal(err)
 			return
 		}
 		defer f.Close()
 		input = f
-	} else if len(os.Args) == 1 {
+	} else if flag.NArg() == 0 {
 		input = os.Stdin
 	} else {
 		log.Fatalf(help_text)
 		return
 	}
 
+	output := bufio.NewWriter(os.Stdout)
+	w, err := newEntryWriter(*format, output)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	failed, err := convert(input, w, os.Stderr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := output.Flush(); err != nil {
+		log.Fatal(err)
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d line(s) failed to parse\n", failed)
+		os.Exit(1)
+	}
+}
+
+// convert parses the lines of input and writes them to w, reporting lines
+// which fail to parse to errOut. It returns the number of failed lines.
+func convert(input io.Reader, w entryWriter, errOut io.Writer) (int, error) {
 	scanner := bufio.NewScanner(input)
 	lineParser := cccedictparser.NewLineParser()
+	lineNo := 0
+	failed := 0
 
 	for scanner.Scan() {
-		if err := scanner.Err(); err != nil {
-			log.Fatal(err)
-		}
+		lineNo++
+		l := strings.TrimSuffix(scanner.Text(), "\r")
 
-		l := scanner.Text()
+		if strings.HasPrefix(l, "#") || strings.TrimSpace(l) == "" {
+			if err := w.Comment(l); err != nil {
+				return failed, err
+			}
+			continue
+		}
 
 		ci, err := lineParser.ParseLine(l)
 
-		if err == nil {
-			os.Stdout.WriteString(ci.String() + "\n")
-		} else {
-			os.Stdout.WriteString(err.Error() + "\n")
+		if err != nil {
+			failed++
+			fmt.Fprintf(errOut, "line %d: %s\n", lineNo, err.Error())
+			continue
+		}
+
+		if err := w.Write(ci); err != nil {
+			return failed, err
 		}
 	}
+
+	return failed, scanner.Err()
 }
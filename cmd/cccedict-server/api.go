@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	cccedictparser "github.com/xDestx/cc-cedict-reader"
+)
+
+const default_limit = 20
+const max_limit = 100
+
+// max entries a completion looks at before paging
+const max_completions = 1000
+
+// max size of a text to segment
+const max_segment_bytes = 64 * 1024
+
+// api serves the JSON lookup endpoints over the current version of a
+// dictionary. Each request reads a single version; responses are cached by
+// ETag, which changes with the response when a new version is loaded.
+type api struct {
+	holder *cccedictparser.Holder
+	// adminToken enables POST /admin/reload for requests bearing it
+	adminToken string
+}
+
+func newAPI(holder *cccedictparser.Holder, adminToken string) *api {
+	return &api{holder: holder, adminToken: adminToken}
+}
+
+func (a *api) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/lookup", a.lookup)
+	mux.HandleFunc("GET /v1/pinyin", a.pinyin)
+	mux.HandleFunc("GET /v1/english", a.english)
+	mux.HandleFunc("GET /v1/complete", a.complete)
+	mux.HandleFunc("GET /v1/segment", a.segment)
+	mux.HandleFunc("POST /v1/segment", a.segment)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, http.StatusOK, versionOf(a.holder.Current()))
+	})
+	if a.adminToken != "" {
+		mux.HandleFunc("POST /admin/reload", a.reload)
+	}
+	return mux
+}
+
+type versionResponse struct {
+	Generation int       `json:"generation"`
+	Entries    int       `json:"entries"`
+	ModTime    time.Time `json:"mod_time"`
+	LoadedAt   time.Time `json:"loaded_at"`
+	LineErrors int       `json:"line_errors"`
+}
+
+func versionOf(l *cccedictparser.Loaded) versionResponse {
+	return versionResponse{
+		Generation: l.Generation,
+		Entries:    l.Index.Len(),
+		ModTime:    l.ModTime,
+		LoadedAt:   l.LoadedAt,
+		LineErrors: len(l.LineErrors),
+	}
+}
+
+// reload loads the dictionary file again. When the new file fails its
+// checks the current version keeps being served and the error is returned.
+func (a *api) reload(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.adminToken)) != 1 {
+		writeError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := a.holder.Reload(); err != nil {
+		writeError(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeJSON(w, r, http.StatusOK, versionOf(a.holder.Current()))
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// page is a paginated list of entries.
+type page struct {
+	Total   int                 `json:"total"`
+	Offset  int                 `json:"offset"`
+	Limit   int                 `json:"limit"`
+	Results []cccedictparser.Ci `json:"results"`
+}
+
+// writeJSON writes v with an ETag computed from the body, answering 304 Not
+// Modified when the client has it already.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json; charset=utf-8")
+
+	if status == http.StatusOK {
+		sum := sha256.Sum256(buf.Bytes())
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		h.Set("ETag", etag)
+		h.Set("Cache-Control", "no-cache")
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// etagMatches reports whether an If-None-Match header lists etag.
+func etagMatches(header string, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+		if v == etag || v == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	writeJSON(w, r, status, errorResponse{Error: msg})
+}
+
+// pagination reads the offset and limit parameters.
+func pagination(r *http.Request) (offset int, limit int, ok bool) {
+	offset, limit = 0, default_limit
+	q := r.URL.Query()
+
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, 0, false
+		}
+		offset = n
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return 0, 0, false
+		}
+		limit = min(n, max_limit)
+	}
+	return offset, limit, true
+}
+
+// writePage writes a page of results, or an error for bad parameters.
+func writePage(w http.ResponseWriter, r *http.Request, results []cccedictparser.Ci) {
+	offset, limit, ok := pagination(r)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "offset must be >= 0 and limit >= 1")
+		return
+	}
+
+	p := page{Total: len(results), Offset: offset, Limit: limit, Results: []cccedictparser.Ci{}}
+	if offset < len(results) {
+		p.Results = results[offset:min(offset+limit, len(results))]
+	}
+	writeJSON(w, r, http.StatusOK, p)
+}
+
+// query returns the required parameter name, writing an error if missing.
+func query(w http.ResponseWriter, r *http.Request, name string) (string, bool) {
+	v := strings.TrimSpace(r.URL.Query().Get(name))
+	if v == "" {
+		writeError(w, r, http.StatusBadRequest, "missing parameter "+name)
+		return "", false
+	}
+	return v, true
+}
+
+// lookup finds entries by simplified or traditional headword: ?word=中国
+func (a *api) lookup(w http.ResponseWriter, r *http.Request) {
+	if word, ok := query(w, r, "word"); ok {
+		writePage(w, r, a.holder.Index().Lookup(word))
+	}
+}
+
+// pinyin finds entries by pinyin, with or without tones: ?q=zhong1guo2,
+// ?q=zhongguo or ?q=zhōngguó
+func (a *api) pinyin(w http.ResponseWriter, r *http.Request) {
+	q, ok := query(w, r, "q")
+	if !ok {
+		return
+	}
+	idx := a.holder.Index()
+	found := idx.LookupPinyin(q)
+	if len(found) == 0 {
+		if numbered, err := cccedictparser.NumberedPinyin(q); err == nil {
+			found = idx.LookupPinyin(numbered)
+		}
+	}
+	writePage(w, r, found)
+}
+
+// english finds entries whose gloss has all the words: ?q=to study
+func (a *api) english(w http.ResponseWriter, r *http.Request) {
+	if q, ok := query(w, r, "q"); ok {
+		writePage(w, r, a.holder.Index().SearchEnglish(q))
+	}
+}
+
+// complete finds entries whose headword or pinyin starts with the prefix,
+// shortest first: ?q=zhong
+func (a *api) complete(w http.ResponseWriter, r *http.Request) {
+	if q, ok := query(w, r, "q"); ok {
+		writePage(w, r, a.holder.Index().Trie().Prefix(q, max_completions))
+	}
+}
+
+type segmentResponse struct {
+	Segments []cccedictparser.Segment `json:"segments"`
+}
+
+// segment splits text into dictionary words: ?text=我们是学生, or the text as
+// the body of a POST request
+func (a *api) segment(w http.ResponseWriter, r *http.Request) {
+	var text string
+	if r.Method == http.MethodPost {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, max_segment_bytes))
+		if err != nil {
+			writeError(w, r, http.StatusRequestEntityTooLarge, "text too large")
+			return
+		}
+		text = string(body)
+	} else {
+		v, ok := query(w, r, "text")
+		if !ok {
+			return
+		}
+		text = v
+	}
+
+	segments := a.holder.Index().Segment(text)
+	if segments == nil {
+		segments = []cccedictparser.Segment{}
+	}
+	writeJSON(w, r, http.StatusOK, segmentResponse{Segments: segments})
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cccedictparser "github.com/xDestx/cc-cedict-reader"
+)
+
+func newTestHandler(t *testing.T) http.Handler {
+	t.Helper()
+	holder, err := cccedictparser.NewHolder("../../testdata/sample.u8", cccedictparser.HolderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newAPI(holder, "").handler()
+}
+
+func get(t *testing.T, h http.Handler, path string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func getPage(t *testing.T, h http.Handler, path string) page {
+	t.Helper()
+	rec := get(t, h, path, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: status %d: %s", path, rec.Code, rec.Body.String())
+	}
+	var p page
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func headwords(p page) string {
+	words := make([]string, 0, len(p.Results))
+	for _, ci := range p.Results {
+		words = append(words, ci.Jiantizi+" "+ci.PinyinRaw)
+	}
+	return strings.Join(words, "|")
+}
+
+func TestEndpoints(t *testing.T) {
+	h := newTestHandler(t)
+
+	cases := []struct {
+		path     string
+		expected string
+	}{
+		{"/v1/lookup?word=" + url.QueryEscape("中國"), "中国 Zhong1 guo2"},
+		{"/v1/lookup?word=" + url.QueryEscape("中"), "中 Zhong1|中 zhong1|中 zhong4"},
+		{"/v1/pinyin?q=zhong1", "中 Zhong1|中 zhong1"},
+		{"/v1/pinyin?q=" + url.QueryEscape("xuéxí"), "学习 xue2 xi2"},
+		{"/v1/english?q=" + url.QueryEscape("to study"), "学 xue2|学习 xue2 xi2"},
+		{"/v1/complete?q=daxue", "大学 da4 xue2|大学生 da4 xue2 sheng1"},
+		{"/v1/lookup?word=" + url.QueryEscape("不存在"), ""},
+	}
+
+	for _, v := range cases {
+		if got := headwords(getPage(t, h, v.path)); got != v.expected {
+			t.Errorf("%s: expected %s, got %s", v.path, v.expected, got)
+		}
+	}
+}
+
+func TestPagination(t *testing.T) {
+	h := newTestHandler(t)
+	path := "/v1/lookup?word=" + url.QueryEscape("中")
+
+	p := getPage(t, h, path+"&offset=1&limit=1")
+	if p.Total != 3 || p.Offset != 1 || p.Limit != 1 || headwords(p) != "中 zhong1" {
+		t.Errorf("unexpected page %+v", p)
+	}
+	if p := getPage(t, h, path+"&offset=10"); p.Total != 3 || len(p.Results) != 0 {
+		t.Errorf("unexpected page %+v", p)
+	}
+	if p := getPage(t, h, path+"&limit=1000"); p.Limit != max_limit {
+		t.Errorf("limit not capped: %d", p.Limit)
+	}
+
+	for _, bad := range []string{"&offset=-1", "&limit=0", "&limit=x"} {
+		if rec := get(t, h, path+bad, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", bad, rec.Code)
+		}
+	}
+	if rec := get(t, h, "/v1/lookup", nil); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "missing parameter word") {
+		t.Errorf("unexpected response to missing parameter: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestETag(t *testing.T) {
+	h := newTestHandler(t)
+	path := "/v1/lookup?word=" + url.QueryEscape("好")
+
+	rec := get(t, h, path, nil)
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+
+	if again := get(t, h, path, nil); again.Header().Get("ETag") != etag {
+		t.Errorf("ETag changed between identical requests")
+	}
+	if other := get(t, h, "/v1/lookup?word="+url.QueryEscape("中"), nil); other.Header().Get("ETag") == etag {
+		t.Errorf("same ETag for different responses")
+	}
+
+	cached := get(t, h, path, http.Header{"If-None-Match": {`"other", ` + etag}})
+	if cached.Code != http.StatusNotModified || cached.Body.Len() != 0 {
+		t.Errorf("expected 304 without body, got %d", cached.Code)
+	}
+}
+
+func TestSegmentEndpoint(t *testing.T) {
+	h := newTestHandler(t)
+
+	check := func(rec *httptest.ResponseRecorder) {
+		t.Helper()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Segments []struct {
+				Text    string            `json:"text"`
+				Start   int               `json:"start"`
+				End     int               `json:"end"`
+				Entries []json.RawMessage `json:"entries"`
+			} `json:"segments"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+
+		var words []string
+		for _, s := range resp.Segments {
+			words = append(words, s.Text)
+		}
+		if got := strings.Join(words, "|"); got != "我们|是|大学生|。" {
+			t.Errorf("unexpected segments %s", got)
+		}
+		if resp.Segments[3].Entries == nil || len(resp.Segments[3].Entries) != 0 || len(resp.Segments[2].Entries) != 1 {
+			t.Errorf("unexpected entries %s", rec.Body.String())
+		}
+	}
+
+	check(get(t, h, "/v1/segment?text="+url.QueryEscape("我们是大学生。"), nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/segment", strings.NewReader("我们是大学生。"))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	check(rec)
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/segment", strings.NewReader(strings.Repeat("好", max_segment_bytes)))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/v1/segment", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", rec.Code)
+	}
+}
+
+func post(h http.Handler, path string, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cedict.u8")
+	if err := os.WriteFile(path, []byte("好 好 [hao3] /good/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	holder, err := cccedictparser.NewHolder(path, cccedictparser.HolderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newAPI(holder, "secret").handler()
+
+	if rec := post(h, "/admin/reload", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", rec.Code)
+	}
+	if rec := post(h, "/admin/reload", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", rec.Code)
+	}
+
+	if err := os.WriteFile(path, []byte("好 好 [hao3] /good/\n你好 你好 [ni3 hao3] /hello/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rec := post(h, "/admin/reload", "secret")
+	var v versionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil || rec.Code != http.StatusOK || v.Generation != 2 || v.Entries != 2 {
+		t.Errorf("unexpected reload response %d %s", rec.Code, rec.Body.String())
+	}
+	if got := headwords(getPage(t, h, "/v1/lookup?word="+url.QueryEscape("你好"))); got != "你好 ni3 hao3" {
+		t.Errorf("new version not served: %s", got)
+	}
+
+	if err := os.WriteFile(path, []byte("broken line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if rec := post(h, "/admin/reload", "secret"); rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "line 1") {
+		t.Errorf("unexpected response to a broken file %d %s", rec.Code, rec.Body.String())
+	}
+	if got := headwords(getPage(t, h, "/v1/lookup?word="+url.QueryEscape("你好"))); got != "你好 ni3 hao3" {
+		t.Errorf("current version not kept: %s", got)
+	}
+
+	if rec := get(t, h, "/healthz", nil); !strings.Contains(rec.Body.String(), `"generation":2`) {
+		t.Errorf("unexpected health %s", rec.Body.String())
+	}
+
+	// without a token there is no admin endpoint
+	if rec := post(newTestHandler(t), "/admin/reload", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	cccedictparser "github.com/xDestx/cc-cedict-reader"
+)
+
+const help_text = "Format: <cmd> --dict <cc-cedict file> [--addr host:port] [--poll interval] [--admin-token token]\nEx: cccedict-server --dict cedict_ts.u8 --addr :8080\nEx: curl 'localhost:8080/v1/lookup?word=中国'\nThe dictionary is reloaded on SIGHUP, on POST /admin/reload (with --admin-token) and, with --poll, when the file changes.\n"
+
+func main() {
+	dictPath := flag.String("dict", "", "cc-cedict dictionary file (required)")
+	addr := flag.String("addr", ":8080", "address to listen on")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 10*time.Second, "time given to open requests on shutdown")
+	poll := flag.Duration("poll", 0, "check the dictionary file for changes at this interval, 0 disables")
+	adminToken := flag.String("admin-token", os.Getenv("CCCEDICT_ADMIN_TOKEN"), "bearer token enabling POST /admin/reload (default $CCCEDICT_ADMIN_TOKEN)")
+	maxLineErrors := flag.Int("max-line-errors", -1, "reject a dictionary file with more lines failing to parse, -1 for no limit")
+	minRatio := flag.Float64("min-ratio", 0.9, "reject a reloaded file with fewer entries than this ratio of the current one")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), help_text)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *dictPath == "" || flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	holder, err := cccedictparser.NewHolder(*dictPath, cccedictparser.HolderOptions{
+		MaxLineErrors: *maxLineErrors,
+		MinRatio:      *minRatio,
+		OnReload: func(l *cccedictparser.Loaded, err error) {
+			if err != nil {
+				log.Printf("reload failed, keeping the current dictionary: %s", err.Error())
+				return
+			}
+			for _, e := range l.LineErrors {
+				log.Printf("%s: %s", *dictPath, e.Error())
+			}
+			log.Printf("loaded %d entries (generation %d)", l.Index.Len(), l.Generation)
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           newAPI(holder, *adminToken).handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go holder.ReloadOnSignal(ctx, syscall.SIGHUP)
+	if *poll > 0 {
+		go holder.Poll(ctx, *poll)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", *addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+
+	log.Print("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Fatal(err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	cccedictparser "github.com/xDestx/cc-cedict-reader"
+)
+
+const help_text = "Format: <cmd> --dict <cc-cedict file> [--out dir] [--title title]\nEx: cccedict-site --dict cedict_ts.u8 --out site\n"
+
+func main() {
+	dictPath := flag.String("dict", "", "cc-cedict dictionary file (required)")
+	out := flag.String("out", "site", "output directory")
+	title := flag.String("title", "CC-CEDICT", "site title")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), help_text)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *dictPath == "" || flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*dictPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	entries, err := cccedictparser.ReadDictionary(f)
+	f.Close()
+	if err != nil {
+		// entries which failed to parse are left out
+		fmt.Fprintln(os.Stderr, err.Error())
+	}
+
+	s, err := newSite(*title, cccedictparser.NewIndex(entries))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := s.write(*out); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d pages to %s\n", len(s.words), *out)
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cccedictparser "github.com/xDestx/cc-cedict-reader"
+)
+
+//go:embed templates/*.html
+var templates embed.FS
+
+//go:embed static/*
+var static embed.FS
+
+// pages_dir holds the headword pages inside the output directory
+const pages_dir = "w"
+
+// site renders one page per simplified headword of a dictionary.
+type site struct {
+	title string
+	idx   *cccedictparser.Index
+	// words are the simplified headwords, sorted
+	words []string
+	pages map[string]bool
+	tmpl  *template.Template
+}
+
+func newSite(title string, idx *cccedictparser.Index) (*site, error) {
+	tmpl, err := template.ParseFS(templates, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &site{title: title, idx: idx, pages: make(map[string]bool), tmpl: tmpl}
+	for _, ci := range idx.Entries() {
+		if !s.pages[ci.Jiantizi] {
+			s.pages[ci.Jiantizi] = true
+			s.words = append(s.words, ci.Jiantizi)
+		}
+	}
+	sort.Strings(s.words)
+	return s, nil
+}
+
+// pageName is the file name of the page of a headword. Bytes which are not
+// safe in file names on common systems are written as ~XX.
+func pageName(word string) string {
+	var b strings.Builder
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		if c < 0x20 || c == 0x7f || strings.IndexByte(`/\:*?"<>|%#~`, c) >= 0 || (i == 0 && c == '.') {
+			fmt.Fprintf(&b, "~%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String() + ".html"
+}
+
+// pageLink is the link to the page of a headword from another page.
+func pageLink(word string) string {
+	return url.PathEscape(pageName(word))
+}
+
+type reading struct {
+	Simplified  string
+	Traditional string
+	Pinyin      template.HTML
+	Numbered    string
+	Zhuyin      string
+	Senses      []template.HTML
+	Tags        []string
+}
+
+type character struct {
+	Char     string
+	Variant  string
+	Link     string
+	Readings []string
+}
+
+type pageData struct {
+	Title      string
+	Word       string
+	Root       string
+	Readings   []reading
+	Characters []character
+}
+
+// glossHTML escapes a sense and links the words it refers to which have a
+// page.
+func (s *site) glossHTML(gloss string) template.HTML {
+	var b strings.Builder
+	last := 0
+	for _, ref := range cccedictparser.GlossReferences(gloss) {
+		if !s.pages[ref.Jiantizi] {
+			continue
+		}
+		b.WriteString(html.EscapeString(gloss[last:ref.Start]))
+		fmt.Fprintf(&b, `<a class="xref" href="%s">%s</a>`, pageLink(ref.Jiantizi), html.EscapeString(gloss[ref.Start:ref.End]))
+		last = ref.End
+	}
+	b.WriteString(html.EscapeString(gloss[last:]))
+	return template.HTML(b.String())
+}
+
+// characters breaks a word of several characters down into its characters
+// and their readings.
+func (s *site) characters(word string, variant string) []character {
+	runes := []rune(word)
+	if len(runes) < 2 {
+		return nil
+	}
+	variants := []rune(variant)
+
+	out := make([]character, 0, len(runes))
+	for i, r := range runes {
+		c := character{Char: string(r)}
+		if len(variants) == len(runes) && variants[i] != r {
+			c.Variant = string(variants[i])
+		}
+
+		for _, ci := range s.idx.Lookup(c.Char) {
+			if c.Link == "" && s.pages[ci.Jiantizi] {
+				c.Link = pageLink(ci.Jiantizi)
+			}
+			r := ci.DiacriticPinyin()
+			if senses := ci.Senses(); len(senses) > 0 {
+				r += ": " + senses[0]
+			}
+			c.Readings = append(c.Readings, r)
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func (s *site) page(word string) pageData {
+	p := pageData{Title: s.title, Word: word, Root: "../"}
+
+	variant := word
+	for _, ci := range s.idx.Lookup(word) {
+		r := reading{
+			Simplified:  ci.Jiantizi,
+			Traditional: ci.Fantizi,
+			Pinyin:      template.HTML(ci.ToneHTML()),
+			Numbered:    ci.PinyinRaw,
+			Zhuyin:      ci.Zhuyin(),
+			Tags:        ci.Tags(),
+		}
+		for _, g := range ci.Gloss {
+			r.Senses = append(r.Senses, s.glossHTML(g))
+		}
+		if ci.Jiantizi == word && variant == word {
+			variant = ci.Fantizi
+		}
+		p.Readings = append(p.Readings, r)
+	}
+
+	p.Characters = s.characters(word, variant)
+	return p
+}
+
+// searchItem is one entry of the search index, with short keys to keep the
+// index small: simplified, traditional, pinyin, toneless pinyin, first sense
+// and page.
+type searchItem struct {
+	S string `json:"s"`
+	T string `json:"t,omitempty"`
+	P string `json:"p"`
+	K string `json:"k"`
+	G string `json:"g"`
+	U string `json:"u"`
+}
+
+// tonelessPinyin is the pinyin searched for: lower case, without tones,
+// spaces or apostrophes, ü as v.
+func tonelessPinyin(ci cccedictparser.Ci) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == ' ' || r == ':' || r == '\'' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.ReplaceAll(ci.PinyinRaw, "u:", "v")))
+}
+
+func (s *site) searchIndex() []searchItem {
+	items := make([]searchItem, 0, s.idx.Len())
+	for _, ci := range s.idx.Entries() {
+		item := searchItem{
+			S: ci.Jiantizi,
+			P: ci.DiacriticPinyin(),
+			K: tonelessPinyin(ci),
+			U: pages_dir + "/" + pageLink(ci.Jiantizi),
+		}
+		if ci.Fantizi != ci.Jiantizi {
+			item.T = ci.Fantizi
+		}
+		if senses := ci.Senses(); len(senses) > 0 {
+			item.G = senses[0]
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func (s *site) renderFile(path string, name string, data any) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := s.tmpl.ExecuteTemplate(f, name, data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// write renders the site into dir: index.html with the search, the static
+// files, the search index (search.json, and search-index.js which browsers
+// also load from file:// URLs) and one page per headword under w/.
+func (s *site) write(dir string) error {
+	if err := os.MkdirAll(filepath.Join(dir, pages_dir), 0o755); err != nil {
+		return err
+	}
+
+	err := fs.WalkDir(static, "static", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := static.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, d.Name()), data, 0o644)
+	})
+	if err != nil {
+		return err
+	}
+
+	index, err := json.Marshal(s.searchIndex())
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "search.json"), index, 0o644); err != nil {
+		return err
+	}
+	script := "var SEARCH_INDEX = " + string(index) + ";\n"
+	if err := os.WriteFile(filepath.Join(dir, "search-index.js"), []byte(script), 0o644); err != nil {
+		return err
+	}
+
+	if err := s.renderFile(filepath.Join(dir, "index.html"), "index.html", pageData{Title: s.title, Root: ""}); err != nil {
+		return err
+	}
+
+	for _, w := range s.words {
+		if err := s.renderFile(filepath.Join(dir, pages_dir, pageName(w)), "page.html", s.page(w)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cccedictparser "github.com/xDestx/cc-cedict-reader"
+)
+
+const site_dictionary = `# test dictionary
+個 个 [ge4] /individual/this/that/size/classifier for people or objects in general/
+人 人 [ren2] /person/people/CL:個|个[ge4],位[wei4]/
+中國 中国 [Zhong1 guo2] /China/
+中國人 中国人 [Zhong1 guo2 ren2] /Chinese person/see also 中國|中国[Zhong1 guo2]/
+國 国 [guo2] /country/nation/
+中 中 [zhong1] /within/among/
+中 中 [zhong4] /to hit (the mark)/
+A/B A/B [A B] /test <entry>/
+`
+
+func newTestSite(t *testing.T) *site {
+	t.Helper()
+	entries, err := cccedictparser.ReadDictionary(strings.NewReader(site_dictionary))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := newSite("Test", cccedictparser.NewIndex(entries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestPageName(t *testing.T) {
+	cases := map[string]string{
+		"中国":  "中国.html",
+		"A/B": "A~2FB.html",
+		"..":  "~2E..html",
+		"50%": "50~25.html",
+		"a~b": "a~7Eb.html",
+		"Q?":  "Q~3F.html",
+	}
+
+	for word, expected := range cases {
+		if got := pageName(word); got != expected {
+			t.Errorf("%s: expected %s, got %s", word, expected, got)
+		}
+	}
+
+	if got := pageLink("中国"); got != "%E4%B8%AD%E5%9B%BD.html" {
+		t.Errorf("unexpected link %s", got)
+	}
+}
+
+func TestGlossHTML(t *testing.T) {
+	s := newTestSite(t)
+
+	cases := map[string]string{
+		"CL:個|个[ge4],位[wei4]":         `CL:<a class="xref" href="` + pageLink("个") + `">個|个[ge4]</a>,位[wei4]`,
+		"see also 中國|中国[Zhong1 guo2]": `see also <a class="xref" href="` + pageLink("中国") + `">中國|中国[Zhong1 guo2]</a>`,
+		"test <entry>":                "test &lt;entry&gt;",
+	}
+
+	for gloss, expected := range cases {
+		if got := string(s.glossHTML(gloss)); got != expected {
+			t.Errorf("%s: expected %s, got %s", gloss, expected, got)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	s := newTestSite(t)
+
+	p := s.page("中国人")
+	if len(p.Readings) != 1 || p.Readings[0].Traditional != "中國人" || p.Readings[0].Numbered != "Zhong1 guo2 ren2" {
+		t.Fatalf("unexpected readings %+v", p.Readings)
+	}
+
+	if len(p.Characters) != 3 {
+		t.Fatalf("expected 3 characters, got %d", len(p.Characters))
+	}
+	zhong := p.Characters[0]
+	if zhong.Char != "中" || zhong.Variant != "" || zhong.Link != pageLink("中") || strings.Join(zhong.Readings, "|") != "zhōng: within|zhòng: to hit (the mark)" {
+		t.Errorf("unexpected character %+v", zhong)
+	}
+	guo := p.Characters[1]
+	if guo.Char != "国" || guo.Variant != "國" || guo.Link != pageLink("国") {
+		t.Errorf("unexpected character %+v", guo)
+	}
+
+	if p := s.page("中"); len(p.Readings) != 2 || p.Characters != nil {
+		t.Errorf("unexpected page for 中 %+v", p)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	s := newTestSite(t)
+	dir := t.TempDir()
+	if err := s.write(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"index.html", "style.css", "search.js", "search-index.js", "search.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s: %s", name, err.Error())
+		}
+	}
+
+	files, err := os.ReadDir(filepath.Join(dir, pages_dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(s.words) {
+		t.Errorf("expected %d pages, got %d", len(s.words), len(files))
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, pages_dir, pageName("人")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<title>人 - Test</title>",
+		`<span class="tone2" style="color:#02b31c">rén</span>`,
+		`<a class="xref" href="` + pageLink("个") + `">個|个[ge4]</a>`,
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("page of 人 does not contain %s", want)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "search.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []searchItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 8 {
+		t.Fatalf("expected 8 items, got %d", len(items))
+	}
+	if items[2] != (searchItem{S: "中国", T: "中國", P: "Zhōng guó", K: "zhongguo", G: "China", U: "w/" + pageLink("中国")}) {
+		t.Errorf("unexpected item %+v", items[2])
+	}
+	for _, it := range items {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(strings.ReplaceAll(it.U, pageLink(it.S), pageName(it.S))))); err != nil {
+			t.Errorf("%s: page not found", it.S)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Searches the index written by cccedict-site. search-index.js sets
+// SEARCH_INDEX so the search also works from file:// URLs; otherwise
+// search.json is fetched.
+(function () {
+  "use strict";
+
+  var maxResults = 50;
+  var query = document.getElementById("query");
+  var results = document.getElementById("results");
+  var entries = typeof SEARCH_INDEX !== "undefined" ? SEARCH_INDEX : null;
+
+  // pinyin as in the index: lower case, no tones, spaces or apostrophes, ü as v
+  function pinyinKey(q) {
+    return q.toLowerCase()
+      .replace(/ü|u:/g, "v")
+      .normalize("NFD")
+      .replace(/[\u0300-\u036f]/g, "")
+      .replace(/[0-9\s']/g, "");
+  }
+
+  // score ranks exact matches before prefix matches before other matches,
+  // 0 is no match
+  function score(e, q, key, words) {
+    if (e.s === q || e.t === q) return 4;
+    if (key && e.k === key) return 3;
+    if (e.s.indexOf(q) === 0 || (e.t && e.t.indexOf(q) === 0)) return 2;
+    if (key && e.k.indexOf(key) === 0) return 1.5;
+    if (words.length > 0) {
+      var g = e.g.toLowerCase();
+      for (var i = 0; i < words.length; i++) {
+        if (g.indexOf(words[i]) < 0) return 0;
+      }
+      return 1;
+    }
+    return 0;
+  }
+
+  function search() {
+    var q = query.value.trim();
+    results.textContent = "";
+    if (!entries || q === "") return;
+
+    var key = /^[a-zA-Z0-9\s'üÜāáǎàēéěèīíǐìōóǒòūúǔùǖǘǚǜ:]+$/.test(q) ? pinyinKey(q) : "";
+    var words = /[a-zA-Z]/.test(q) ? q.toLowerCase().split(/\s+/) : [];
+
+    var found = [];
+    for (var i = 0; i < entries.length; i++) {
+      var s = score(entries[i], q, key, words);
+      if (s > 0) found.push({ score: s, order: i, entry: entries[i] });
+    }
+    found.sort(function (a, b) { return b.score - a.score || a.order - b.order; });
+
+    found.slice(0, maxResults).forEach(function (f) {
+      var e = f.entry;
+      var li = document.createElement("li");
+      var a = document.createElement("a");
+      a.href = e.u;
+      a.className = "hanzi";
+      a.textContent = e.s + (e.t ? " (" + e.t + ")" : "");
+      li.appendChild(a);
+      li.appendChild(document.createTextNode(" " + e.p + " — " + e.g));
+      results.appendChild(li);
+    });
+  }
+
+  query.addEventListener("input", search);
+
+  if (!entries) {
+    fetch("search.json")
+      .then(function (r) { return r.json(); })
+      .then(function (data) { entries = data; search(); });
+  }
+})();
@@ -0,0 +1,276 @@
+package cccedictparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// marked vowels for tones 1 to 4
+var tone_marked_vowels = map[rune][4]rune{
+	'a': {'ā', 'á', 'ǎ', 'à'},
+	'e': {'ē', 'é', 'ě', 'è'},
+	'i': {'ī', 'í', 'ǐ', 'ì'},
+	'o': {'ō', 'ó', 'ǒ', 'ò'},
+	'u': {'ū', 'ú', 'ǔ', 'ù'},
+	'ü': {'ǖ', 'ǘ', 'ǚ', 'ǜ'},
+	'A': {'Ā', 'Á', 'Ǎ', 'À'},
+	'E': {'Ē', 'É', 'Ě', 'È'},
+	'I': {'Ī', 'Í', 'Ǐ', 'Ì'},
+	'O': {'Ō', 'Ó', 'Ǒ', 'Ò'},
+	'U': {'Ū', 'Ú', 'Ǔ', 'Ù'},
+	'Ü': {'Ǖ', 'Ǘ', 'Ǚ', 'Ǜ'},
+}
+
+// toneMarkIndex returns the index of the rune carrying the tone mark: a or e
+// if present, the o of ou, otherwise the last vowel.
+func toneMarkIndex(runes []rune) int {
+	lower := []rune(strings.ToLower(string(runes)))
+	last := -1
+	for i, r := range lower {
+		switch r {
+		case 'a', 'e':
+			return i
+		case 'o':
+			if i+1 < len(lower) && lower[i+1] == 'u' {
+				return i
+			}
+			last = i
+		case 'i', 'u', 'ü':
+			last = i
+		}
+	}
+	return last
+}
+
+// Diacritic returns the syllable with a tone mark, e.g. "zhōng" for zhong1
+// and "lǜ" for lv4. Neutral tones are left unmarked and letters, punctuation
+// and unknown syllables are returned unchanged.
+func (p PinyinV1) Diacritic() string {
+	if p.Type != Normal {
+		return p.Sound
+	}
+
+	runes := []rune(strings.NewReplacer("v", "ü", "V", "Ü").Replace(p.Sound))
+	if p.Tone < T1 || p.Tone > T4 {
+		return string(runes)
+	}
+
+	if i := toneMarkIndex(runes); i >= 0 {
+		runes[i] = tone_marked_vowels[runes[i]][p.Tone-1]
+	}
+	return string(runes)
+}
+
+// Diacritic returns the word with tone marks, with an apostrophe before
+// syllables starting with a, e or o, e.g. "Xī'ān".
+func (p PinyinV2) Diacritic() string {
+	var b strings.Builder
+	for i, v := range p.Word {
+		s := v.Diacritic()
+		if i > 0 && v.Type == Normal && strings.ContainsRune("aeoAEO", firstRune(v.Sound)) {
+			b.WriteByte('\'')
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
+
+// DiacriticPinyin returns the reading of ci with tone marks, words separated
+// by spaces, e.g. "Zhōng guó".
+func (ci Ci) DiacriticPinyin() string {
+	words := make([]string, 0, len(ci.Pinyin))
+	for _, w := range ci.Pinyin {
+		words = append(words, w.Diacritic())
+	}
+	return strings.Join(words, " ")
+}
+
+type markedVowel struct {
+	base rune
+	tone Tone
+}
+
+var tone_mark_bases = func() map[rune]markedVowel {
+	m := make(map[rune]markedVowel)
+	for base, marked := range tone_marked_vowels {
+		for i, r := range marked {
+			m[r] = markedVowel{base: base, tone: Tone(i + 1)}
+		}
+	}
+	return m
+}()
+
+type pinyinLetter struct {
+	r    rune
+	tone Tone
+	// a capital within the word, which pinyin would not have
+	latin bool
+}
+
+// NumberedPinyin converts pinyin written with tone marks or tone numbers into
+// cc-cedict numbered syllables separated by spaces: "Zhōngguó" and
+// "zhong1guo2" give "Zhong1 guo2" and "zhong1 guo2". Syllables without a
+// tone mark are neutral (5). Words are split into syllables following the
+// pinyin spelling rules, so "fāngàn" is fan1 gan4 while "fāng'àn" is
+// fang1 an4. Latin words are kept as CC-CEDICT writes them: upper-case runs
+// such as "OK", "DNA" or the T of "Txù", and words without tones which are
+// not pinyin, such as "gamma".
+func NumberedPinyin(s string) (string, error) {
+	pym := pinyin_syllables
+	var out []string
+
+	for _, word := range strings.Fields(s) {
+		// Latin letters and punctuation are written as is, like CC-CEDICT
+		if len(word) == 1 && !(word[0] >= 'a' && word[0] <= 'z') {
+			out = append(out, word)
+			continue
+		}
+		for _, part := range strings.Split(word, "'") {
+			if part == "" {
+				continue
+			}
+			if len(part) > 1 && isLatinRun(part, true) {
+				out = append(out, part)
+				continue
+			}
+			syllables, err := numberedSyllables(pym, part)
+			if err != nil {
+				if isLatinRun(part, false) {
+					out = append(out, part)
+					continue
+				}
+				return "", fmt.Errorf("cannot read pinyin (%s): %w", s, err)
+			}
+			out = append(out, syllables...)
+		}
+	}
+
+	return strings.Join(out, " "), nil
+}
+
+// isLatinRun reports whether s is made of ASCII letters only, upper-case ones
+// if upper is set.
+func isLatinRun(s string, upper bool) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'A' && c <= 'Z' || !upper && c >= 'a' && c <= 'z') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isLatinCapital reports whether v is an upper-case ASCII letter without a
+// tone, which within a word starts a Latin run rather than a syllable.
+func isLatinCapital(v pinyinLetter) bool {
+	return v.r >= 'A' && v.r <= 'Z' && v.tone == None
+}
+
+func numberedSyllables(pym map[string]bool, part string) ([]string, error) {
+	part = strings.NewReplacer("u:", "v", "U:", "V", "ü", "v", "Ü", "V").Replace(part)
+
+	// letters with the tone carried by marked vowels or trailing numbers
+	var letters []pinyinLetter
+	for _, r := range part {
+		if mv, ok := tone_mark_bases[r]; ok {
+			base := mv.base
+			if base == 'ü' {
+				base = 'v'
+			} else if base == 'Ü' {
+				base = 'V'
+			}
+			letters = append(letters, pinyinLetter{r: base, tone: mv.tone})
+			continue
+		}
+		if tone, err := getTone(r); err == nil {
+			if len(letters) == 0 {
+				return nil, errors.New("tone number without syllable")
+			}
+			// the number closes a syllable, mark it on its last letter
+			letters[len(letters)-1].tone = tone
+			letters = append(letters, pinyinLetter{r: '|'})
+			continue
+		}
+		letters = append(letters, pinyinLetter{r: r, latin: len(letters) > 0 && r >= 'A' && r <= 'Z'})
+	}
+
+	var syllables []string
+	if !segmentPinyin(pym, letters, &syllables) {
+		return nil, fmt.Errorf("unrecognized syllables in (%s)", part)
+	}
+	return syllables, nil
+}
+
+// segmentPinyin splits letters into syllables, longest first. A syllable may
+// carry one tone and the next syllable cannot start with a, e or o, which
+// pinyin would separate with an apostrophe. '|' marks a forced boundary.
+func segmentPinyin(pym map[string]bool, letters []pinyinLetter, out *[]string) bool {
+	if len(letters) == 0 {
+		return true
+	}
+	if letters[0].r == '|' {
+		return segmentPinyin(pym, letters[1:], out)
+	}
+
+	for l := min(ime_max_syllable_len, len(letters)); l > 0; l-- {
+		var b strings.Builder
+		tone := None
+		valid := true
+		for _, v := range letters[:l] {
+			if v.r == '|' || (v.tone != None && tone != None) || v.latin {
+				valid = false
+				break
+			}
+			if v.tone != None {
+				tone = v.tone
+			}
+			b.WriteRune(v.r)
+		}
+		if !valid || !pym[strings.ToLower(b.String())] {
+			continue
+		}
+
+		if l < len(letters) && strings.ContainsRune("aeoAEO", letters[l].r) && !letters[l].latin {
+			continue
+		}
+
+		if tone == None {
+			tone = T5
+		}
+
+		n := len(*out)
+		*out = append(*out, b.String()+string(rune('0'+tone)))
+		if segmentPinyin(pym, letters[l:], out) {
+			return true
+		}
+		*out = (*out)[:n]
+	}
+
+	// otherwise a run of capitals is kept as Latin letters, e.g. "OK"
+	l := 0
+	for l < len(letters) && isLatinCapital(letters[l]) {
+		l++
+	}
+	if l > 0 {
+		var b strings.Builder
+		for _, v := range letters[:l] {
+			b.WriteRune(v.r)
+		}
+		n := len(*out)
+		*out = append(*out, b.String())
+		if segmentPinyin(pym, letters[l:], out) {
+			return true
+		}
+		*out = (*out)[:n]
+	}
+
+	return false
+}
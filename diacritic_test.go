@@ -0,0 +1,107 @@
+package cccedictparser
+
+import "testing"
+
+func TestDiacritic(t *testing.T) {
+	tests := []testItem{
+		{Name: "diacritic_Syllable", Test: diacritic_Syllable},
+		{Name: "diacritic_Ci", Test: diacritic_Ci},
+		{Name: "diacritic_Numbered", Test: diacritic_Numbered},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func diacritic_Syllable(t *testing.T) {
+	cases := []testCase[string]{
+		{Sentence: "zhong1", Expected: "zhōng"},
+		{Sentence: "guo2", Expected: "guó"},
+		{Sentence: "hao3", Expected: "hǎo"},
+		{Sentence: "xie4", Expected: "xiè"},
+		{Sentence: "dou1", Expected: "dōu"},
+		{Sentence: "liu2", Expected: "liú"},
+		{Sentence: "gui4", Expected: "guì"},
+		{Sentence: "lu:4", Expected: "lǜ"},
+		{Sentence: "nu:3", Expected: "nǚ"},
+		{Sentence: "lu:e4", Expected: "lüè"},
+		{Sentence: "er2", Expected: "ér"},
+		{Sentence: "Ai4", Expected: "Ài"},
+		{Sentence: "men5", Expected: "men"},
+		{Sentence: "r5", Expected: "r"},
+		{Sentence: "K", Expected: "K"},
+		{Sentence: "xx5", Expected: "xx"},
+	}
+
+	for _, v := range cases {
+		ci, err := ParseLine("字 字 [" + v.Sentence + "] /gloss/")
+		if err != nil {
+			t.Errorf("error: %s. Pinyin %s", err.Error(), v.Sentence)
+			continue
+		}
+		if got := ci.Pinyin[0].Word[0].Diacritic(); got != v.Expected {
+			t.Errorf("%s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
+
+func diacritic_Ci(t *testing.T) {
+	cases := []testCase[string]{
+		{Sentence: "中國 中国 [Zhong1 guo2] /China/", Expected: "Zhōng guó"},
+		{Sentence: "西安 西安 [[Xi1an1]] /Xi'an/", Expected: "Xī'ān"},
+		{Sentence: "皮實 皮实 [[pi2shi5]] /durable/", Expected: "píshi"},
+		{Sentence: "卡拉OK 卡拉OK [ka3 la1 O K] /karaoke/", Expected: "kǎ lā O K"},
+	}
+
+	for _, v := range cases {
+		ci, err := ParseLine(v.Sentence)
+		if err != nil {
+			t.Errorf("error: %s. Line %s", err.Error(), v.Sentence)
+			continue
+		}
+		if got := ci.DiacriticPinyin(); got != v.Expected {
+			t.Errorf("%s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
+
+func diacritic_Numbered(t *testing.T) {
+	cases := []testCase[string]{
+		{Sentence: "Zhōngguó", Expected: "Zhong1 guo2"},
+		{Sentence: "zhong1guo2", Expected: "zhong1 guo2"},
+		{Sentence: "fāngàn", Expected: "fan1 gan4"},
+		{Sentence: "fāng'àn", Expected: "fang1 an4"},
+		{Sentence: "Xī'ān", Expected: "Xi1 an1"},
+		{Sentence: "nǚ", Expected: "nv3"},
+		{Sentence: "xièxie", Expected: "xie4 xie5"},
+		{Sentence: "nǐ hǎo", Expected: "ni3 hao3"},
+		{Sentence: "Kǎlā OK", Expected: "Ka3 la1 OK"},
+		{Sentence: "kǎlāOK", Expected: "ka3 la1 OK"},
+		{Sentence: "DNA jiàndìng", Expected: "DNA jian4 ding4"},
+		{Sentence: "DNAjiàndìng", Expected: "DNA jian4 ding4"},
+		{Sentence: "T xù", Expected: "T xu4"},
+		{Sentence: "Txù", Expected: "T xu4"},
+		{Sentence: "AA zhì", Expected: "AA zhi4"},
+		{Sentence: "gamma shèxiàn", Expected: "gamma she4 xian4"},
+		{Sentence: "p H zhí", Expected: "p H zhi2"},
+		{Sentence: "qqq", Expected: "qqq"},
+	}
+
+	for _, v := range cases {
+		got, err := NumberedPinyin(v.Sentence)
+		if err != nil {
+			t.Errorf("error: %s. Pinyin %s", err.Error(), v.Sentence)
+			continue
+		}
+		if got != v.Expected {
+			t.Errorf("%s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+
+	for _, v := range []string{"hǎǒ", "qqq3", "qqqā"} {
+		if _, err := NumberedPinyin(v); err == nil {
+			t.Errorf("%s: expected an error", v)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// dictd_b64 is the alphabet of the numbers of a dictd .index file.
+const dictd_b64 = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// DictdOptions describes the database written by WriteDictd.
+type DictdOptions struct {
+	// Short is the name listed by SHOW DB, "CC-CEDICT" when empty.
+	Short string
+	// Info is returned by SHOW INFO.
+	Info string
+	URL  string
+	// Dictzip writes a compressed .dict.dz instead of .dict.
+	Dictzip bool
+}
+
+// dictdNumber writes n in the base64 digits of dictd, most significant first.
+func dictdNumber(n int) string {
+	if n == 0 {
+		return "A"
+	}
+	var b []byte
+	for ; n > 0; n >>= 6 {
+		b = append(b, dictd_b64[n&63])
+	}
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+// dictdFold is the key dictd compares in a UTF-8 allchars database: the word
+// lower-cased, all letters and not only ASCII ones.
+func dictdFold(word string) string {
+	return strings.ToLower(word)
+}
+
+// sortDictdWords sorts words as dictd binary searches them: by the byte order
+// of the folded words, then byte-wise.
+func sortDictdWords[T any](items []T, word func(T) string) {
+	folded := make(map[string]string)
+	fold := func(w string) string {
+		f, ok := folded[w]
+		if !ok {
+			f = dictdFold(w)
+			folded[w] = f
+		}
+		return f
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := word(items[i]), word(items[j])
+		if fa, fb := fold(a), fold(b); fa != fb {
+			return fa < fb
+		}
+		return a < b
+	})
+}
+
+// DictdArticle renders ci as the plain text definition of a DICT database:
+// the headwords and the pinyin on the first line, then the numbered senses
+// indented.
+func DictdArticle(ci Ci) string {
+	var b strings.Builder
+	b.WriteString(ci.Jiantizi)
+	if ci.Fantizi != ci.Jiantizi {
+		b.WriteString(" (" + ci.Fantizi + ")")
+	}
+	b.WriteString(" [" + ci.DiacriticPinyin() + "]\n")
+	for i, g := range ci.Gloss {
+		fmt.Fprintf(&b, "   %d. %s\n", i+1, g)
+	}
+	return b.String()
+}
+
+// DictdKeys returns the index words of ci: the simplified and traditional
+// headwords, the pinyin with tone marks and the pinyin without tones, e.g.
+// 中国, 中國, "Zhōng guó" and "zhongguo".
+func DictdKeys(ci Ci) []string {
+	keys := []string{ci.Jiantizi}
+	for _, k := range []string{ci.Fantizi, ci.DiacriticPinyin(), tonelessKey(ci)} {
+		if k != "" && !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// WriteDictd writes entries as a dictd database into dir: name.index and
+// name.dict (or name.dict.dz). Every entry is one definition, found under the
+// words of DictdKeys. The database is UTF-8 and allchars, so dictd does not
+// strip the hanzi from the index words.
+func WriteDictd(dir string, name string, entries []Ci, opts DictdOptions) error {
+	type indexLine struct {
+		word   string
+		offset int
+		size   int
+	}
+
+	var dict bytes.Buffer
+	var lines []indexLine
+
+	add := func(word string, text string) {
+		lines = append(lines, indexLine{word: word, offset: dict.Len(), size: len(text)})
+		dict.WriteString(text)
+	}
+
+	short := opts.Short
+	if short == "" {
+		short = "CC-CEDICT"
+	}
+	// header entries read by dictd, the text starts with the word
+	add("00-database-utf8", "00-database-utf8\n")
+	add("00-database-allchars", "00-database-allchars\n")
+	add("00-database-short", "00-database-short\n     "+short+"\n")
+	if opts.URL != "" {
+		add("00-database-url", "00-database-url\n     "+opts.URL+"\n")
+	}
+	if opts.Info != "" {
+		add("00-database-info", "00-database-info\n"+opts.Info+"\n")
+	}
+
+	for _, ci := range entries {
+		offset := dict.Len()
+		article := DictdArticle(ci)
+		dict.WriteString(article)
+		for _, k := range DictdKeys(ci) {
+			lines = append(lines, indexLine{word: k, offset: offset, size: len(article)})
+		}
+	}
+
+	sortDictdWords(lines, func(l indexLine) string { return l.word })
+
+	var index bytes.Buffer
+	for _, l := range lines {
+		fmt.Fprintf(&index, "%s\t%s\t%s\n", strings.ReplaceAll(l.word, "\t", " "), dictdNumber(l.offset), dictdNumber(l.size))
+	}
+
+	base := filepath.Join(dir, name)
+
+	if opts.Dictzip {
+		var dz bytes.Buffer
+		if err := writeDictzip(&dz, dict.Bytes()); err != nil {
+			return err
+		}
+		if err := os.WriteFile(base+".dict.dz", dz.Bytes(), 0o644); err != nil {
+			return err
+		}
+	} else if err := os.WriteFile(base+".dict", dict.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	return os.WriteFile(base+".index", index.Bytes(), 0o644)
+}
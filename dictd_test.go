@@ -0,0 +1,207 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDictd(t *testing.T) {
+	tests := []testItem{
+		{Name: "dictd_Number", Test: dictd_Number},
+		{Name: "dictd_Article", Test: dictd_Article},
+		{Name: "dictd_IndexResolves", Test: dictd_IndexResolves},
+		{Name: "dictd_Dictzip", Test: dictd_Dictzip},
+		{Name: "dictd_IndexCollation", Test: dictd_IndexCollation},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func decodeDictdNumber(t *testing.T, s string) int {
+	t.Helper()
+	n := 0
+	for _, c := range s {
+		d := strings.IndexRune(dictd_b64, c)
+		if d < 0 {
+			t.Fatalf("bad number %s", s)
+		}
+		n = n<<6 | d
+	}
+	return n
+}
+
+func dictd_Number(t *testing.T) {
+	cases := []testCase[string]{
+		{Sentence: "0", Expected: "A"},
+		{Sentence: "1", Expected: "B"},
+		{Sentence: "63", Expected: "/"},
+		{Sentence: "64", Expected: "BA"},
+		{Sentence: "4096", Expected: "BAA"},
+	}
+
+	for _, v := range cases {
+		n := 0
+		for _, c := range v.Sentence {
+			n = n*10 + int(c-'0')
+		}
+		if got := dictdNumber(n); got != v.Expected {
+			t.Errorf("%s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+		if back := decodeDictdNumber(t, v.Expected); back != n {
+			t.Errorf("%s: decoded %d", v.Expected, back)
+		}
+	}
+}
+
+func dictd_Article(t *testing.T) {
+	ci, err := ParseLine("中國 中国 [Zhong1 guo2] /China/Middle Kingdom/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "中国 (中國) [Zhōng guó]\n   1. China\n   2. Middle Kingdom\n"
+	if got := DictdArticle(ci); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	if got := strings.Join(DictdKeys(ci), ","); got != "中国,中國,Zhōng guó,zhongguo" {
+		t.Errorf("unexpected keys %s", got)
+	}
+}
+
+func readDictdIndex(t *testing.T, index []byte, dict []byte) map[string][]string {
+	t.Helper()
+	words := make(map[string][]string)
+	prev := ""
+	for _, l := range strings.Split(strings.TrimSuffix(string(index), "\n"), "\n") {
+		fields := strings.Split(l, "\t")
+		if len(fields) != 3 {
+			t.Fatalf("bad index line %q", l)
+		}
+		if f, fp := dictdFold(fields[0]), dictdFold(prev); f < fp || f == fp && fields[0] < prev {
+			t.Errorf("index not sorted: %s after %s", fields[0], prev)
+		}
+		prev = fields[0]
+
+		offset, size := decodeDictdNumber(t, fields[1]), decodeDictdNumber(t, fields[2])
+		if offset+size > len(dict) {
+			t.Fatalf("%s: definition out of range", fields[0])
+		}
+		words[fields[0]] = append(words[fields[0]], string(dict[offset:offset+size]))
+	}
+	return words
+}
+
+func dictd_IndexResolves(t *testing.T) {
+	dir := t.TempDir()
+	entries := loadSample(t)
+	if err := WriteDictd(dir, "cedict", entries, DictdOptions{URL: "https://www.mdbg.net/chinese/dictionary?page=cc-cedict"}); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := os.ReadFile(filepath.Join(dir, "cedict.index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict, err := os.ReadFile(filepath.Join(dir, "cedict.dict"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	words := readDictdIndex(t, index, dict)
+	if got := words["00-database-short"]; len(got) != 1 || got[0] != "00-database-short\n     CC-CEDICT\n" {
+		t.Errorf("unexpected short name %q", got)
+	}
+	if words["00-database-utf8"] == nil || words["00-database-allchars"] == nil || words["00-database-url"] == nil {
+		t.Errorf("missing database headers")
+	}
+
+	if got := words["中"]; len(got) != 3 {
+		t.Errorf("expected 3 definitions for 中, got %d", len(got))
+	}
+	if got := words["zhongguo"]; len(got) != 1 || got[0] != "中国 (中國) [Zhōng guó]\n   1. China\n" {
+		t.Errorf("unexpected definitions for zhongguo %q", got)
+	}
+
+	for _, ci := range entries {
+		found := false
+		for _, d := range words[ci.Fantizi] {
+			found = found || d == DictdArticle(ci)
+		}
+		if !found {
+			t.Errorf("%s: definition not found", FormatLine(ci))
+		}
+	}
+}
+
+func dictd_Dictzip(t *testing.T) {
+	dir := t.TempDir()
+	entries := loadSample(t)
+	if err := WriteDictd(dir, "cedict", entries, DictdOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteDictd(dir, "cedictz", entries, DictdOptions{Dictzip: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	plain, err := os.ReadFile(filepath.Join(dir, "cedict.dict"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join(dir, "cedictz.dict.dz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, plain) {
+		t.Errorf("dictzip data differs from .dict")
+	}
+}
+
+func dictd_IndexCollation(t *testing.T) {
+	dir := t.TempDir()
+	extra, err := ReadDictionary(strings.NewReader("俄羅斯 俄罗斯 [E2 luo2 si1] /Russia/\n" +
+		"厄瓜多爾 厄瓜多尔 [E4 gua1 duo1 er3] /Ecuador/\n" +
+		"鵝 鹅 [e2] /goose/\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteDictd(dir, "cedict", append(loadSample(t), extra...), DictdOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	index, err := os.ReadFile(filepath.Join(dir, "cedict.index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var words []string
+	for _, l := range strings.Split(strings.TrimSuffix(string(index), "\n"), "\n") {
+		words = append(words, strings.Split(l, "\t")[0])
+	}
+
+	// dictd folds the query and binary searches the folded words, so a
+	// lower-case query finds the capitalised tone marked keys
+	for _, q := range []string{"é luó sī", "É luó sī", "è guā duō ěr", "é"} {
+		fq := dictdFold(q)
+		i := sort.Search(len(words), func(i int) bool { return dictdFold(words[i]) >= fq })
+		if i == len(words) || dictdFold(words[i]) != fq {
+			t.Errorf("binary search for %s failed", q)
+		}
+	}
+}
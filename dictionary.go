@@ -0,0 +1,143 @@
+package cccedictparser
+
+import "fmt"
+
+// Dictionary is the query interface shared by the dictionary backends: Index
+// (through Index.Dictionary), DiskIndex and Federation. Errors come from
+// backends which read their data on demand.
+type Dictionary interface {
+	// Lookup returns the entries whose simplified or traditional headword
+	// is word.
+	Lookup(word string) ([]Ci, error)
+	// Search returns the entries whose headword is query, then those read
+	// as query in pinyin, then those whose gloss contains every English word
+	// of query. Identical entries are returned once.
+	Search(query string) ([]Ci, error)
+	// Prefix returns up to limit entries whose headword or toneless pinyin
+	// starts with prefix, shorter keys first. A limit <= 0 returns every
+	// match.
+	Prefix(prefix string, limit int) ([]Ci, error)
+	// Iterate calls fn with every entry in dictionary order until fn
+	// returns false.
+	Iterate(fn func(Ci) bool) error
+}
+
+var (
+	_ Dictionary = indexDictionary{}
+	_ Dictionary = (*DiskIndex)(nil)
+	_ Dictionary = (*Federation)(nil)
+)
+
+// appendUnique appends the entries of lists to out, skipping the entries
+// equal to one in seen, and records them in seen.
+func appendUnique(out []Ci, seen map[string]bool, lists ...[]Ci) []Ci {
+	for _, list := range lists {
+		for _, ci := range list {
+			k := FormatLine(ci)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			out = append(out, ci)
+		}
+	}
+	return out
+}
+
+type indexDictionary struct {
+	idx *Index
+}
+
+// Dictionary returns idx as a Dictionary. Prefix uses idx.Trie(), which is
+// built on first use. No method returns an error.
+func (idx *Index) Dictionary() Dictionary {
+	return indexDictionary{idx: idx}
+}
+
+func (d indexDictionary) Lookup(word string) ([]Ci, error) {
+	return d.idx.Lookup(word), nil
+}
+
+func (d indexDictionary) Search(query string) ([]Ci, error) {
+	return appendUnique(make([]Ci, 0), make(map[string]bool), d.idx.Lookup(query), d.idx.LookupPinyin(query), d.idx.SearchEnglish(query)), nil
+}
+
+func (d indexDictionary) Prefix(prefix string, limit int) ([]Ci, error) {
+	return d.idx.Trie().Prefix(prefix, limit), nil
+}
+
+func (d indexDictionary) Iterate(fn func(Ci) bool) error {
+	for _, ci := range d.idx.entries {
+		if !fn(ci) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Federation queries several dictionaries as one, such as CC-CEDICT with a
+// user dictionary. Results follow the order of the dictionaries and an entry
+// identical to one from an earlier dictionary is dropped. Errors stop the
+// query.
+type Federation struct {
+	dicts []Dictionary
+}
+
+// NewFederation combines dicts, the first having the highest priority.
+func NewFederation(dicts ...Dictionary) *Federation {
+	return &Federation{dicts: dicts}
+}
+
+func (f *Federation) gather(query func(d Dictionary) ([]Ci, error), limit int) ([]Ci, error) {
+	out := make([]Ci, 0)
+	seen := make(map[string]bool)
+	for i, d := range f.dicts {
+		entries, err := query(d)
+		if err != nil {
+			return nil, fmt.Errorf("dictionary %d: %w", i, err)
+		}
+		out = appendUnique(out, seen, entries)
+		if limit > 0 && len(out) >= limit {
+			return out[:limit], nil
+		}
+	}
+	return out, nil
+}
+
+// Lookup returns the Lookup results of every dictionary in turn.
+func (f *Federation) Lookup(word string) ([]Ci, error) {
+	return f.gather(func(d Dictionary) ([]Ci, error) { return d.Lookup(word) }, 0)
+}
+
+// Search returns the Search results of every dictionary in turn.
+func (f *Federation) Search(query string) ([]Ci, error) {
+	return f.gather(func(d Dictionary) ([]Ci, error) { return d.Search(query) }, 0)
+}
+
+// Prefix returns the Prefix results of every dictionary in turn, up to limit
+// in total.
+func (f *Federation) Prefix(prefix string, limit int) ([]Ci, error) {
+	return f.gather(func(d Dictionary) ([]Ci, error) { return d.Prefix(prefix, limit) }, limit)
+}
+
+// Iterate iterates every dictionary in turn. Identical entries are not
+// dropped, as that would mean remembering every entry.
+func (f *Federation) Iterate(fn func(Ci) bool) error {
+	stopped := false
+	for i, d := range f.dicts {
+		err := d.Iterate(func(ci Ci) bool {
+			if !fn(ci) {
+				stopped = true
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return fmt.Errorf("dictionary %d: %w", i, err)
+		}
+		if stopped {
+			return nil
+		}
+	}
+	return nil
+}
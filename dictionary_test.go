@@ -0,0 +1,183 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDictionary(t *testing.T) {
+	tests := []testItem{
+		{Name: "dictionary_BackendsAgree", Test: dictionary_BackendsAgree},
+		{Name: "dictionary_Search", Test: dictionary_Search},
+		{Name: "dictionary_Iterate", Test: dictionary_Iterate},
+		{Name: "dictionary_Federation", Test: dictionary_Federation},
+		{Name: "dictionary_FederationErrors", Test: dictionary_FederationErrors},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func dictionary_BackendsAgree(t *testing.T) {
+	extra, err := ReadDictionary(strings.NewReader("3C 3C [san1 C] /computers, communications and consumer electronics/\n" +
+		"1 1 [yi1] /one/\n" +
+		"詞 词 [ci2] /word/\n" +
+		"ＯＫ ＯＫ [O K] /OK/\n" +
+		"Ｘ光 Ｘ光 [X guang1] /X-ray/\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := append(loadSample(t), extra...)
+
+	mem := NewIndex(entries).Dictionary()
+	data := writeDiskIndex(t, entries, DiskIndexOptions{BlockSize: 64})
+	disk, err := NewDiskIndex(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries := []string{"中国", "中國", "中", "hao", "zhong1 guo2", "China", "to hit", "不存在", "",
+		"c", "3c", "3C", "1", "san1", "ci2", "ok", "卡拉ok", "卡拉OK", "ｏｋ", "ｘ", "Ｘ光", "x"}
+	for _, q := range queries {
+		for name, query := range map[string]func(d Dictionary) ([]Ci, error){
+			"lookup":   func(d Dictionary) ([]Ci, error) { return d.Lookup(q) },
+			"search":   func(d Dictionary) ([]Ci, error) { return d.Search(q) },
+			"prefix":   func(d Dictionary) ([]Ci, error) { return d.Prefix(q, 0) },
+			"prefix 2": func(d Dictionary) ([]Ci, error) { return d.Prefix(q, 2) },
+		} {
+			expected, err := query(mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := query(disk)
+			if err != nil || !reflect.DeepEqual(got, expected) {
+				t.Errorf("%s %q: expected %v, got %v (%v)", name, q, headwords(expected), headwords(got), err)
+			}
+		}
+	}
+
+	// tone numbers are dropped from pinyin only
+	cases := []testCase[string]{
+		{Sentence: "c", Expected: "词,餐馆"},
+		{Sentence: "3c", Expected: "3C"},
+		{Sentence: "1", Expected: "1"},
+	}
+	for _, v := range cases {
+		for _, d := range []Dictionary{mem, disk} {
+			got, err := d.Prefix(v.Sentence, 0)
+			if err != nil || strings.Join(headwords(got), ",") != v.Expected {
+				t.Errorf("%T prefix %s: expected %s, got %v (%v)", d, v.Sentence, v.Expected, headwords(got), err)
+			}
+		}
+	}
+}
+
+func dictionary_Search(t *testing.T) {
+	d := loadSampleIndex(t).Dictionary()
+
+	cases := []testCase[[]string]{
+		{Sentence: "中国", Expected: []string{"中国"}},
+		{Sentence: "hao3", Expected: []string{"好"}},
+		{Sentence: "China", Expected: []string{"中", "中国", "西安"}},
+		{Sentence: "不存在", Expected: []string{}},
+	}
+
+	for _, v := range cases {
+		got, err := d.Search(v.Sentence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(headwords(got), ",") != strings.Join(v.Expected, ",") {
+			t.Errorf("search %s: expected %v, got %v", v.Sentence, v.Expected, headwords(got))
+		}
+	}
+}
+
+func dictionary_Iterate(t *testing.T) {
+	idx := loadSampleIndex(t)
+	disk := newSampleDiskIndex(t, DiskIndexOptions{})
+
+	for _, d := range []Dictionary{idx.Dictionary(), disk} {
+		var got []Ci
+		if err := d.Iterate(func(ci Ci) bool {
+			got = append(got, ci)
+			return true
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, idx.Entries()) {
+			t.Errorf("%T: expected %d entries in order, got %d", d, idx.Len(), len(got))
+		}
+
+		n := 0
+		d.Iterate(func(Ci) bool {
+			n++
+			return n < 3
+		})
+		if n != 3 {
+			t.Errorf("%T: expected iteration to stop after 3 entries, got %d", d, n)
+		}
+	}
+}
+
+func dictionary_Federation(t *testing.T) {
+	user, err := ReadDictionary(strings.NewReader("中國 中国 [Zhong1 guo2] /Middle Kingdom/\n國 国 [guo2] /country/nation/state/national/CL:個|个[ge4]/\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := loadSampleIndex(t)
+	f := NewFederation(NewIndex(user).Dictionary(), base.Dictionary())
+
+	got, err := f.Lookup("中国")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Gloss[0] != "Middle Kingdom" {
+		t.Errorf("expected the user entry then the base entry, got %v", got)
+	}
+
+	// identical entries are returned once
+	if got, _ := f.Lookup("国"); len(got) != 1 {
+		t.Errorf("expected 1 entry for 国, got %d", len(got))
+	}
+	if got, _ := f.Search("Middle Kingdom"); len(got) != 1 {
+		t.Errorf("expected 1 entry for Middle Kingdom, got %d", len(got))
+	}
+	if got, _ := f.Prefix("中", 2); len(got) != 2 {
+		t.Errorf("expected the limit of 2 entries, got %d", len(got))
+	}
+
+	n := 0
+	f.Iterate(func(Ci) bool {
+		n++
+		return true
+	})
+	if n != len(user)+base.Len() {
+		t.Errorf("expected %d entries, got %d", len(user)+base.Len(), n)
+	}
+}
+
+type failingDictionary struct {
+	err error
+}
+
+func (d failingDictionary) Lookup(string) ([]Ci, error)      { return nil, d.err }
+func (d failingDictionary) Search(string) ([]Ci, error)      { return nil, d.err }
+func (d failingDictionary) Prefix(string, int) ([]Ci, error) { return nil, d.err }
+func (d failingDictionary) Iterate(func(Ci) bool) error      { return d.err }
+
+func dictionary_FederationErrors(t *testing.T) {
+	errBroken := errors.New("broken")
+	f := NewFederation(loadSampleIndex(t).Dictionary(), failingDictionary{err: errBroken})
+
+	if _, err := f.Lookup("中国"); !errors.Is(err, errBroken) || !strings.HasPrefix(err.Error(), "dictionary 1: ") {
+		t.Errorf("expected the error of dictionary 1, got %v", err)
+	}
+	if err := f.Iterate(func(Ci) bool { return true }); !errors.Is(err, errBroken) {
+		t.Errorf("expected the error of dictionary 1, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+// dictzip_chunk_len is the default chunk size of the dictzip tool.
+const dictzip_chunk_len = 58315
+
+// max chunk count so the chunk table fits the 64KiB gzip extra field
+const dictzip_max_chunks = (0xffff - 10) / 2
+
+// writeDictzip compresses data as a dictzip file: a gzip file whose deflate
+// stream is fully flushed every chunk, with the compressed chunk sizes stored
+// in an "RA" extra field so readers can seek without decompressing it all.
+// Plain gzip readers decompress it as usual.
+func writeDictzip(w io.Writer, data []byte) error {
+	chunks := (len(data) + dictzip_chunk_len - 1) / dictzip_chunk_len
+	if chunks == 0 {
+		chunks = 1
+	}
+	if chunks > dictzip_max_chunks {
+		return errors.New("data too large for dictzip")
+	}
+
+	var compressed bytes.Buffer
+	sizes := make([]uint16, 0, chunks)
+
+	for i := 0; i < chunks; i++ {
+		start := i * dictzip_chunk_len
+		end := min(start+dictzip_chunk_len, len(data))
+		before := compressed.Len()
+
+		// a fresh compressor per chunk never refers back to an earlier
+		// chunk, which is what a full flush guarantees
+		fw, err := flate.NewWriter(&compressed, flate.BestCompression)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data[start:end]); err != nil {
+			return err
+		}
+		if i == chunks-1 {
+			err = fw.Close()
+		} else {
+			err = fw.Flush()
+		}
+		if err != nil {
+			return err
+		}
+
+		size := compressed.Len() - before
+		if size > 0xffff {
+			return errors.New("dictzip chunk does not compress")
+		}
+		sizes = append(sizes, uint16(size))
+	}
+
+	// RA subfield: version, chunk length, chunk count, chunk sizes
+	sub := make([]byte, 0, 6+2*len(sizes))
+	sub = binary.LittleEndian.AppendUint16(sub, 1)
+	sub = binary.LittleEndian.AppendUint16(sub, dictzip_chunk_len)
+	sub = binary.LittleEndian.AppendUint16(sub, uint16(len(sizes)))
+	for _, s := range sizes {
+		sub = binary.LittleEndian.AppendUint16(sub, s)
+	}
+
+	extra := []byte{'R', 'A'}
+	extra = binary.LittleEndian.AppendUint16(extra, uint16(len(sub)))
+	extra = append(extra, sub...)
+
+	// ID1 ID2 CM FLG(FEXTRA) MTIME(4) XFL OS(unix)
+	header := []byte{0x1f, 0x8b, 8, 4, 0, 0, 0, 0, 2, 3}
+	header = binary.LittleEndian.AppendUint16(header, uint16(len(extra)))
+	header = append(header, extra...)
+
+	trailer := binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(data))
+	trailer = binary.LittleEndian.AppendUint32(trailer, uint32(len(data)))
+
+	for _, part := range [][]byte{header, compressed.Bytes(), trailer} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDictzip(t *testing.T) {
+	tests := []testItem{
+		{Name: "dictzip_GzipCompatible", Test: dictzip_GzipCompatible},
+		{Name: "dictzip_ChunksDecodeAlone", Test: dictzip_ChunksDecodeAlone},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+// dictzipData is large enough for several chunks and not too compressible.
+func dictzipData() []byte {
+	var b strings.Builder
+	for i := 0; b.Len() < 3*dictzip_chunk_len; i++ {
+		b.WriteString(strings.Repeat(string(rune('a'+i%26)), i%7+1))
+		b.WriteString(string(rune(0x4e00 + i%2000)))
+	}
+	return []byte(b.String())
+}
+
+func dictzip_GzipCompatible(t *testing.T) {
+	for _, data := range [][]byte{dictzipData(), []byte("short"), {}} {
+		var buf bytes.Buffer
+		if err := writeDictzip(&buf, data); err != nil {
+			t.Fatal(err)
+		}
+
+		zr, err := gzip.NewReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, data) {
+			t.Errorf("decompressed data differs (%d bytes, expected %d)", len(out), len(data))
+		}
+	}
+}
+
+func dictzip_ChunksDecodeAlone(t *testing.T) {
+	data := dictzipData()
+
+	var buf bytes.Buffer
+	if err := writeDictzip(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	raw := buf.Bytes()
+
+	xlen := int(binary.LittleEndian.Uint16(raw[10:]))
+	extra := raw[12 : 12+xlen]
+	if string(extra[:2]) != "RA" {
+		t.Fatalf("expected RA subfield, got %q", extra[:2])
+	}
+
+	chlen := int(binary.LittleEndian.Uint16(extra[6:]))
+	chcnt := int(binary.LittleEndian.Uint16(extra[8:]))
+	if expected := (len(data) + chlen - 1) / chlen; chcnt != expected || chcnt < 3 {
+		t.Fatalf("expected %d chunks, got %d", expected, chcnt)
+	}
+
+	offset := 12 + xlen
+	for i := 0; i < chcnt; i++ {
+		size := int(binary.LittleEndian.Uint16(extra[10+2*i:]))
+		out, err := io.ReadAll(flate.NewReader(bytes.NewReader(raw[offset : offset+size])))
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("chunk %d: %s", i, err.Error())
+		}
+
+		end := min((i+1)*chlen, len(data))
+		if !bytes.Equal(out, data[i*chlen:end]) {
+			t.Errorf("chunk %d does not decode on its own", i)
+		}
+		offset += size
+	}
+}
@@ -0,0 +1,628 @@
+package cccedictparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// A disk index is little endian:
+//
+//	header     magic "CCEDDISK", version u16, key count u16, entry count u32
+//	entries    per entry: uvarint length, then the entry as a cc-cedict line
+//	blocks     per key, sorted records: uvarint key length, key, uvarint
+//	           entry offset
+//	directory  per key: block count u32, then per block: offset u64,
+//	           length u32, crc32c u32, uvarint first key length, first key
+//	trailer    entries end u64, directory offset u64, directory length u32,
+//	           directory crc32c u32, magic "CCEDDISK"
+//
+// Only the directory is held in memory: a lookup binary searches the first
+// keys and reads the one or two blocks which can hold the key.
+const disk_index_magic = "CCEDDISK"
+const disk_index_version = 2
+
+const (
+	disk_index_header_len  = 16
+	disk_index_trailer_len = 32
+)
+
+// DiskKey selects the key of a DiskIndex lookup.
+type DiskKey int
+
+const (
+	// DiskSimplified and DiskTraditional are the headwords lower-cased.
+	DiskSimplified DiskKey = iota
+	DiskTraditional
+	// DiskToneless is the pinyin without tones, spaces or case, "zhongguo".
+	DiskToneless
+	disk_key_count
+)
+
+// DiskIndexOptions configures WriteDiskIndex.
+type DiskIndexOptions struct {
+	// BlockSize is the size in bytes above which a block of keys is closed,
+	// 4096 when zero. Smaller blocks read less per lookup and keep more first
+	// keys in memory.
+	BlockSize int
+}
+
+func diskKeys(ci Ci) [disk_key_count]string {
+	return [disk_key_count]string{
+		DiskSimplified:  normalizeHeadwordKey(ci.Jiantizi),
+		DiskTraditional: normalizeHeadwordKey(ci.Fantizi),
+		DiskToneless:    tonelessKey(ci),
+	}
+}
+
+type diskRecord struct {
+	key    string
+	offset uint64
+}
+
+// WriteDiskIndex writes entries as a disk index for NewDiskIndex and
+// OpenDiskIndex. Entries keep their order and are stored as cc-cedict lines.
+func WriteDiskIndex(w io.Writer, entries []Ci, opts DiskIndexOptions) error {
+	blockSize := opts.BlockSize
+	if blockSize <= 0 {
+		blockSize = 4096
+	}
+
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+
+	header := []byte(disk_index_magic)
+	header = binary.LittleEndian.AppendUint16(header, disk_index_version)
+	header = binary.LittleEndian.AppendUint16(header, uint16(disk_key_count))
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(entries)))
+	cw.Write(header)
+
+	var tables [disk_key_count][]diskRecord
+	var buf []byte
+	for _, ci := range entries {
+		offset := uint64(cw.n)
+		for k, key := range diskKeys(ci) {
+			if key != "" {
+				tables[k] = append(tables[k], diskRecord{key: key, offset: offset})
+			}
+		}
+
+		line := FormatLine(ci)
+		buf = binary.AppendUvarint(buf[:0], uint64(len(line)))
+		buf = append(buf, line...)
+		cw.Write(buf)
+	}
+	entriesEnd := uint64(cw.n)
+
+	var dir []byte
+	for _, records := range tables {
+		// by key, then in entry order
+		slices.SortStableFunc(records, func(a, b diskRecord) int {
+			return strings.Compare(a.key, b.key)
+		})
+
+		var blocks []byte
+		count := 0
+		block := []byte{}
+		first := ""
+
+		flush := func() {
+			if len(block) == 0 {
+				return
+			}
+			blocks = binary.LittleEndian.AppendUint64(blocks, uint64(cw.n))
+			blocks = binary.LittleEndian.AppendUint32(blocks, uint32(len(block)))
+			blocks = binary.LittleEndian.AppendUint32(blocks, crc32.Checksum(block, snapshot_crc))
+			blocks = binary.AppendUvarint(blocks, uint64(len(first)))
+			blocks = append(blocks, first...)
+			count++
+
+			cw.Write(block)
+			block = block[:0]
+		}
+
+		for _, r := range records {
+			if len(block) == 0 {
+				first = r.key
+			}
+			block = binary.AppendUvarint(block, uint64(len(r.key)))
+			block = append(block, r.key...)
+			block = binary.AppendUvarint(block, r.offset)
+			if len(block) >= blockSize {
+				flush()
+			}
+		}
+		flush()
+
+		dir = binary.LittleEndian.AppendUint32(dir, uint32(count))
+		dir = append(dir, blocks...)
+	}
+
+	trailer := binary.LittleEndian.AppendUint64(nil, entriesEnd)
+	trailer = binary.LittleEndian.AppendUint64(trailer, uint64(cw.n))
+	trailer = binary.LittleEndian.AppendUint32(trailer, uint32(len(dir)))
+	trailer = binary.LittleEndian.AppendUint32(trailer, crc32.Checksum(dir, snapshot_crc))
+	trailer = append(trailer, disk_index_magic...)
+	cw.Write(dir)
+	cw.Write(trailer)
+
+	if cw.err != nil {
+		return cw.err
+	}
+	return bw.Flush()
+}
+
+type diskBlock struct {
+	offset int64
+	length uint32
+	crc    uint32
+	first  string
+}
+
+// DiskIndex is a dictionary kept on disk for devices with little memory.
+// Only the first key of each block of sorted keys is held in memory; lookups
+// read the blocks which can hold the key, then the matching entries. Methods
+// are safe for concurrent use when the underlying io.ReaderAt is, as files are.
+type DiskIndex struct {
+	r          io.ReaderAt
+	count      int
+	entriesEnd int64
+	tables     [disk_key_count][]diskBlock
+	lineParser LineParser
+	closer     io.Closer
+}
+
+// NewDiskIndex reads the directory of the disk index of the given size in r.
+func NewDiskIndex(r io.ReaderAt, size int64) (*DiskIndex, error) {
+	if size < disk_index_header_len+disk_index_trailer_len {
+		return nil, errors.New("not a disk index file")
+	}
+
+	header := make([]byte, disk_index_header_len)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	trailer := make([]byte, disk_index_trailer_len)
+	if _, err := r.ReadAt(trailer, size-disk_index_trailer_len); err != nil {
+		return nil, err
+	}
+	if string(header[:8]) != disk_index_magic || string(trailer[24:]) != disk_index_magic {
+		return nil, errors.New("not a disk index file")
+	}
+	if v := binary.LittleEndian.Uint16(header[8:]); v != disk_index_version {
+		return nil, fmt.Errorf("unsupported disk index version %d", v)
+	}
+	if n := binary.LittleEndian.Uint16(header[10:]); n != uint16(disk_key_count) {
+		return nil, fmt.Errorf("disk index has %d keys, expected %d", n, disk_key_count)
+	}
+
+	d := &DiskIndex{
+		r:          r,
+		count:      int(binary.LittleEndian.Uint32(header[12:])),
+		entriesEnd: int64(binary.LittleEndian.Uint64(trailer)),
+		lineParser: NewLineParser(),
+	}
+
+	dirOffset := binary.LittleEndian.Uint64(trailer[8:])
+	dirLen := uint64(binary.LittleEndian.Uint32(trailer[16:]))
+	if dirOffset > uint64(size) || dirLen > uint64(size)-dirOffset || d.entriesEnd > int64(dirOffset) {
+		return nil, errors.New("disk index directory out of bounds")
+	}
+	dir := make([]byte, dirLen)
+	if _, err := r.ReadAt(dir, int64(dirOffset)); err != nil {
+		return nil, err
+	}
+	if crc32.Checksum(dir, snapshot_crc) != binary.LittleEndian.Uint32(trailer[20:]) {
+		return nil, errors.New("disk index directory checksum mismatch")
+	}
+
+	for k := range d.tables {
+		if len(dir) < 4 {
+			return nil, errors.New("truncated disk index directory")
+		}
+		count := binary.LittleEndian.Uint32(dir)
+		dir = dir[4:]
+
+		for ; count > 0; count-- {
+			if len(dir) < 16 {
+				return nil, errors.New("truncated disk index directory")
+			}
+			b := diskBlock{
+				offset: int64(binary.LittleEndian.Uint64(dir)),
+				length: binary.LittleEndian.Uint32(dir[8:]),
+				crc:    binary.LittleEndian.Uint32(dir[12:]),
+			}
+			n, m := binary.Uvarint(dir[16:])
+			if m <= 0 || n > uint64(len(dir)-16-m) {
+				return nil, errors.New("truncated disk index directory")
+			}
+			b.first = string(dir[16+m : 16+m+int(n)])
+			dir = dir[16+m+int(n):]
+
+			if b.offset < d.entriesEnd || uint64(b.offset)+uint64(b.length) > dirOffset {
+				return nil, errors.New("disk index block out of bounds")
+			}
+			d.tables[k] = append(d.tables[k], b)
+		}
+	}
+
+	return d, nil
+}
+
+// OpenDiskIndex opens the disk index file at path. Close closes the file.
+func OpenDiskIndex(path string) (*DiskIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	d, err := NewDiskIndex(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	d.closer = f
+	return d, nil
+}
+
+// Close closes the file of OpenDiskIndex.
+func (d *DiskIndex) Close() error {
+	if d.closer == nil {
+		return nil
+	}
+	return d.closer.Close()
+}
+
+// Len returns the number of entries.
+func (d *DiskIndex) Len() int {
+	return d.count
+}
+
+// block reads and checks a block of keys.
+func (d *DiskIndex) block(b diskBlock) ([]byte, error) {
+	data := make([]byte, b.length)
+	if _, err := d.r.ReadAt(data, b.offset); err != nil {
+		return nil, err
+	}
+	if crc32.Checksum(data, snapshot_crc) != b.crc {
+		return nil, fmt.Errorf("disk index block at %d: checksum mismatch", b.offset)
+	}
+	return data, nil
+}
+
+// scan calls match on the records of table key from the first block which
+// can hold keys >= from, in order, until match returns false.
+func (d *DiskIndex) scan(key DiskKey, from string, match func(key []byte, offset int64) bool) error {
+	blocks := d.tables[key]
+	// the block before the first one starting at or after from can end
+	// with from
+	start := sort.Search(len(blocks), func(i int) bool { return blocks[i].first >= from })
+	if start > 0 {
+		start--
+	}
+
+	for _, b := range blocks[start:] {
+		data, err := d.block(b)
+		if err != nil {
+			return err
+		}
+		for len(data) > 0 {
+			n, m := binary.Uvarint(data)
+			if m <= 0 || n > uint64(len(data)-m) {
+				return fmt.Errorf("disk index block at %d: malformed record", b.offset)
+			}
+			k := data[m : m+int(n)]
+			data = data[m+int(n):]
+			offset, m := binary.Uvarint(data)
+			if m <= 0 {
+				return fmt.Errorf("disk index block at %d: malformed record", b.offset)
+			}
+			data = data[m:]
+
+			if string(k) < from {
+				continue
+			}
+			if !match(k, int64(offset)) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// entry reads and parses the entry at offset.
+func (d *DiskIndex) entry(offset int64) (Ci, error) {
+	if offset < disk_index_header_len || offset >= d.entriesEnd {
+		return Ci{}, fmt.Errorf("disk index entry offset %d out of bounds", offset)
+	}
+
+	buf := make([]byte, min(256, d.entriesEnd-offset))
+	if _, err := d.r.ReadAt(buf, offset); err != nil {
+		return Ci{}, err
+	}
+	n, m := binary.Uvarint(buf)
+	if m <= 0 || uint64(offset)+uint64(m)+n > uint64(d.entriesEnd) {
+		return Ci{}, fmt.Errorf("disk index entry at %d: malformed length", offset)
+	}
+
+	line := buf[m:]
+	if uint64(len(line)) >= n {
+		line = line[:n]
+	} else {
+		line = make([]byte, n)
+		if _, err := d.r.ReadAt(line, offset+int64(m)); err != nil {
+			return Ci{}, err
+		}
+	}
+	return d.lineParser.ParseLine(string(line))
+}
+
+// entries reads the entries at offsets, skipping repeated offsets.
+func (d *DiskIndex) entries(offsets ...[]int64) ([]Ci, error) {
+	seen := make(map[int64]bool)
+	out := make([]Ci, 0)
+	for _, list := range offsets {
+		for _, o := range list {
+			if seen[o] {
+				continue
+			}
+			seen[o] = true
+			ci, err := d.entry(o)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, ci)
+		}
+	}
+	return out, nil
+}
+
+func (d *DiskIndex) find(key DiskKey, q string, prefix bool, limit int) ([]int64, error) {
+	var offsets []int64
+	err := d.scan(key, q, func(k []byte, offset int64) bool {
+		if prefix && !bytes.HasPrefix(k, []byte(q)) || !prefix && string(k) != q {
+			return false
+		}
+		offsets = append(offsets, offset)
+		return limit <= 0 || len(offsets) < limit
+	})
+	return offsets, err
+}
+
+// diskQuery normalizes q for key as the keys were built, the same way as
+// the keys of a Trie.
+func diskQuery(key DiskKey, q string) string {
+	if key == DiskToneless {
+		return normalizeTrieKey(q)
+	}
+	return normalizeHeadwordKey(q)
+}
+
+// ExactKey returns the entries whose key is q, in file order. Case is ignored
+// in headword queries, case, spaces and tone numbers in DiskToneless queries.
+func (d *DiskIndex) ExactKey(key DiskKey, q string) ([]Ci, error) {
+	q = diskQuery(key, q)
+	if q == "" {
+		return []Ci{}, nil
+	}
+	offsets, err := d.find(key, q, false, 0)
+	if err != nil {
+		return nil, err
+	}
+	return d.entries(offsets)
+}
+
+// PrefixKey returns up to limit entries whose key starts with prefix, in key
+// order. A limit <= 0 returns every match.
+func (d *DiskIndex) PrefixKey(key DiskKey, prefix string, limit int) ([]Ci, error) {
+	prefix = diskQuery(key, prefix)
+	if prefix == "" {
+		return []Ci{}, nil
+	}
+	offsets, err := d.find(key, prefix, true, limit)
+	if err != nil {
+		return nil, err
+	}
+	return d.entries(offsets)
+}
+
+// Lookup returns the entries whose simplified or traditional headword is
+// word, like Index.Lookup.
+func (d *DiskIndex) Lookup(word string) ([]Ci, error) {
+	out := make([]Ci, 0)
+	seen := make(map[int64]bool)
+
+	// headword keys ignore case, Lookup does not
+	for _, key := range []DiskKey{DiskSimplified, DiskTraditional} {
+		offsets, err := d.find(key, diskQuery(key, word), false, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range offsets {
+			if seen[o] {
+				continue
+			}
+			ci, err := d.entry(o)
+			if err != nil {
+				return nil, err
+			}
+			if key == DiskSimplified && ci.Jiantizi == word || key == DiskTraditional && ci.Fantizi == word {
+				seen[o] = true
+				out = append(out, ci)
+			}
+		}
+	}
+	return out, nil
+}
+
+// LookupPinyin returns the entries read as pinyin, like Index.LookupPinyin:
+// queries with tone numbers match tones exactly.
+func (d *DiskIndex) LookupPinyin(pinyin string) ([]Ci, error) {
+	entries, err := d.ExactKey(DiskToneless, pinyin)
+	if err != nil {
+		return nil, err
+	}
+
+	q := normalizePinyinQuery(pinyin)
+	if !strings.ContainsAny(q, "12345") {
+		return entries, nil
+	}
+	out := make([]Ci, 0, len(entries))
+	for _, ci := range entries {
+		if tonedKey(ci) == q {
+			out = append(out, ci)
+		}
+	}
+	return out, nil
+}
+
+// each calls fn with the offset and the entry of every entry in file order,
+// reading the entries section sequentially, until fn returns false.
+func (d *DiskIndex) each(fn func(offset int64, ci Ci) bool) error {
+	br := bufio.NewReader(io.NewSectionReader(d.r, disk_index_header_len, d.entriesEnd-disk_index_header_len))
+	offset := int64(disk_index_header_len)
+	var line []byte
+
+	for offset < d.entriesEnd {
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return fmt.Errorf("disk index entry at %d: %w", offset, err)
+		}
+		if n > uint64(d.entriesEnd-offset) {
+			return fmt.Errorf("disk index entry at %d: malformed length", offset)
+		}
+		line = slices.Grow(line[:0], int(n))[:n]
+		if _, err := io.ReadFull(br, line); err != nil {
+			return fmt.Errorf("disk index entry at %d: %w", offset, err)
+		}
+
+		ci, err := d.lineParser.ParseLine(string(line))
+		if err != nil {
+			return fmt.Errorf("disk index entry at %d: %w", offset, err)
+		}
+		if !fn(offset, ci) {
+			return nil
+		}
+		offset += int64(uvarintLen(n)) + int64(n)
+	}
+	return nil
+}
+
+func uvarintLen(n uint64) int {
+	return len(binary.AppendUvarint(nil, n))
+}
+
+// Iterate calls fn with every entry in file order until fn returns false.
+// Entries are read one at a time.
+func (d *DiskIndex) Iterate(fn func(Ci) bool) error {
+	return d.each(func(_ int64, ci Ci) bool { return fn(ci) })
+}
+
+// Search returns the entries whose headword is query, then those read as
+// query in pinyin, then those whose gloss contains every English word of
+// query, like SearchEnglish. Identical entries are returned once. English
+// matching reads the whole file.
+func (d *DiskIndex) Search(query string) ([]Ci, error) {
+	headword, err := d.Lookup(query)
+	if err != nil {
+		return nil, err
+	}
+	pinyin, err := d.LookupPinyin(query)
+	if err != nil {
+		return nil, err
+	}
+
+	exact := make([]Ci, 0)
+	rest := make([]Ci, 0)
+	if words := englishWords(query); len(words) > 0 {
+		phrase := strings.Join(words, " ")
+		err := d.Iterate(func(ci Ci) bool {
+			if !hasEnglishWords(ci, words) {
+				return true
+			}
+			if glossIsPhrase(ci, phrase) {
+				exact = append(exact, ci)
+			} else {
+				rest = append(rest, ci)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return appendUnique(make([]Ci, 0), make(map[string]bool), headword, pinyin, exact, rest), nil
+}
+
+// Prefix returns up to limit entries whose simplified or traditional
+// headword or toneless pinyin starts with prefix, in the order of
+// Trie.Prefix: shorter keys first, headwords before pinyin at equal length.
+// A limit <= 0 returns every match.
+func (d *DiskIndex) Prefix(prefix string, limit int) ([]Ci, error) {
+	type match struct {
+		pinyin bool
+		key    string
+		offset int64
+	}
+	var matches []match
+
+	for key := range d.tables {
+		q := diskQuery(DiskKey(key), prefix)
+		if q == "" {
+			continue
+		}
+		err := d.scan(DiskKey(key), q, func(k []byte, offset int64) bool {
+			if !bytes.HasPrefix(k, []byte(q)) {
+				return false
+			}
+			matches = append(matches, match{pinyin: DiskKey(key) == DiskToneless, key: string(k), offset: offset})
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b match) int {
+		if len(a.key) != len(b.key) {
+			return len(a.key) - len(b.key)
+		}
+		if a.pinyin != b.pinyin {
+			if a.pinyin {
+				return 1
+			}
+			return -1
+		}
+		if c := strings.Compare(a.key, b.key); c != 0 {
+			return c
+		}
+		return int(a.offset - b.offset)
+	})
+
+	offsets := make([]int64, 0, len(matches))
+	seen := make(map[int64]bool)
+	for _, m := range matches {
+		if limit > 0 && len(offsets) == limit {
+			break
+		}
+		if !seen[m.offset] {
+			seen[m.offset] = true
+			offsets = append(offsets, m.offset)
+		}
+	}
+	return d.entries(offsets)
+}
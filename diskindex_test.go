@@ -0,0 +1,194 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiskIndex(t *testing.T) {
+	tests := []testItem{
+		{Name: "diskIndex_Lookup", Test: diskIndex_Lookup},
+		{Name: "diskIndex_ExactKey", Test: diskIndex_ExactKey},
+		{Name: "diskIndex_PrefixKey", Test: diskIndex_PrefixKey},
+		{Name: "diskIndex_Corrupt", Test: diskIndex_Corrupt},
+		{Name: "diskIndex_Open", Test: diskIndex_Open},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func writeDiskIndex(t *testing.T, entries []Ci, opts DiskIndexOptions) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteDiskIndex(&buf, entries, opts); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newSampleDiskIndex(t *testing.T, opts DiskIndexOptions) *DiskIndex {
+	t.Helper()
+	data := writeDiskIndex(t, loadSample(t), opts)
+	d, err := NewDiskIndex(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func diskIndex_Lookup(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	// tiny blocks so equal keys span several of them
+	for _, blockSize := range []int{0, 1, 64} {
+		d := newSampleDiskIndex(t, DiskIndexOptions{BlockSize: blockSize})
+		if d.Len() != idx.Len() {
+			t.Fatalf("expected %d entries, got %d", idx.Len(), d.Len())
+		}
+
+		for _, ci := range idx.Entries() {
+			for _, w := range []string{ci.Jiantizi, ci.Fantizi} {
+				got, err := d.Lookup(w)
+				if expected := idx.Lookup(w); err != nil || !reflect.DeepEqual(got, expected) {
+					t.Errorf("block size %d, lookup %s: expected %v, got %v (%v)", blockSize, w, headwords(expected), headwords(got), err)
+				}
+			}
+			for _, q := range []string{tonedKey(ci), tonelessKey(ci), ci.PinyinRaw} {
+				got, err := d.LookupPinyin(q)
+				if expected := idx.LookupPinyin(q); err != nil || !reflect.DeepEqual(got, expected) {
+					t.Errorf("block size %d, pinyin %s: expected %v, got %v (%v)", blockSize, q, headwords(expected), headwords(got), err)
+				}
+			}
+		}
+
+		for _, q := range []string{"", "不存在", "\xff"} {
+			if got, err := d.Lookup(q); err != nil || len(got) != 0 {
+				t.Errorf("lookup %q: expected nothing, got %v (%v)", q, headwords(got), err)
+			}
+		}
+	}
+}
+
+func diskIndex_ExactKey(t *testing.T) {
+	d := newSampleDiskIndex(t, DiskIndexOptions{BlockSize: 32})
+
+	cases := []struct {
+		key      DiskKey
+		q        string
+		expected string
+	}{
+		{key: DiskSimplified, q: "中国", expected: "中国"},
+		{key: DiskSimplified, q: "中國", expected: ""},
+		{key: DiskTraditional, q: "中國", expected: "中国"},
+		{key: DiskToneless, q: "Zhong1 guo2", expected: "中国"},
+		{key: DiskToneless, q: "zhong", expected: "中,中,中"},
+	}
+
+	for _, c := range cases {
+		got, err := d.ExactKey(c.key, c.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := strings.Join(headwords(got), ","); s != c.expected {
+			t.Errorf("%d %s: expected %s, got %s", c.key, c.q, c.expected, s)
+		}
+	}
+}
+
+func diskIndex_PrefixKey(t *testing.T) {
+	d := newSampleDiskIndex(t, DiskIndexOptions{BlockSize: 32})
+
+	got, err := d.PrefixKey(DiskSimplified, "中", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{}
+	for _, ci := range loadSample(t) {
+		if strings.HasPrefix(ci.Jiantizi, "中") {
+			expected = append(expected, ci.Jiantizi)
+		}
+	}
+	if len(got) != len(expected) || len(got) < 4 {
+		t.Errorf("expected %v, got %v", expected, headwords(got))
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i-1].Jiantizi > got[i].Jiantizi {
+			t.Errorf("prefix results out of key order: %v", headwords(got))
+		}
+	}
+
+	got, err = d.PrefixKey(DiskToneless, "ZHONGG", 0)
+	if err != nil || strings.Join(headwords(got), ",") != "中国,中国人" {
+		t.Errorf("expected 中国,中国人, got %v (%v)", headwords(got), err)
+	}
+
+	got, err = d.PrefixKey(DiskSimplified, "中", 2)
+	if err != nil || len(got) != 2 {
+		t.Errorf("expected 2 entries, got %v (%v)", headwords(got), err)
+	}
+}
+
+func diskIndex_Corrupt(t *testing.T) {
+	data := writeDiskIndex(t, loadSample(t), DiskIndexOptions{BlockSize: 64})
+
+	if _, err := NewDiskIndex(bytes.NewReader(data[:20]), 20); err == nil {
+		t.Error("expected an error for a truncated file")
+	}
+
+	// the last block of the toneless table
+	d, err := NewDiskIndex(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := d.tables[DiskToneless][len(d.tables[DiskToneless])-1]
+	corrupt := bytes.Clone(data)
+	corrupt[last.offset]++
+
+	d, err = NewDiskIndex(bytes.NewReader(corrupt), int64(len(corrupt)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.PrefixKey(DiskToneless, last.first, 0); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("expected a checksum error, got %v", err)
+	}
+	if _, err := d.Lookup("中国"); err != nil {
+		t.Errorf("other blocks should still be readable: %v", err)
+	}
+
+	corrupt = bytes.Clone(data)
+	corrupt[len(corrupt)-disk_index_trailer_len-1]++
+	if _, err := NewDiskIndex(bytes.NewReader(corrupt), int64(len(corrupt))); err == nil {
+		t.Error("expected a directory checksum error")
+	}
+}
+
+func diskIndex_Open(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cedict.idx")
+	if err := os.WriteFile(path, writeDiskIndex(t, loadSample(t), DiskIndexOptions{}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := OpenDiskIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	got, err := d.LookupPinyin("zhong1guo2")
+	if err != nil || len(got) != 1 || got[0].Gloss[0] != "China" {
+		t.Errorf("unexpected entries %v (%v)", got, err)
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", 100)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenDiskIndex(path); err == nil {
+		t.Error("expected an error for a file which is not a disk index")
+	}
+}
@@ -0,0 +1,133 @@
+package cccedictparser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// englishWords splits a gloss into lower case words. Classifier glosses and
+// bracketed pinyin of cross references are not English and are skipped.
+func englishWords(gloss string) []string {
+	if strings.HasPrefix(gloss, "CL:") {
+		return nil
+	}
+
+	var words []string
+	var b strings.Builder
+	inBrackets := false
+
+	flush := func() {
+		if b.Len() > 0 {
+			words = append(words, b.String())
+			b.Reset()
+		}
+	}
+
+	for _, r := range gloss {
+		switch {
+		case r == '[':
+			flush()
+			inBrackets = true
+		case r == ']':
+			inBrackets = false
+		case inBrackets:
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return words
+}
+
+func (idx *Index) buildEnglish() {
+	idx.byEnglish = make(map[string][]int)
+	for i, ci := range idx.entries {
+		for _, g := range ci.Gloss {
+			for _, w := range englishWords(g) {
+				ids := idx.byEnglish[w]
+				if n := len(ids); n == 0 || ids[n-1] != i {
+					idx.byEnglish[w] = append(ids, i)
+				}
+			}
+		}
+	}
+}
+
+// SearchEnglish returns the entries whose gloss contains every word of query.
+// Entries with a gloss equal to the query come first, then the rest in
+// dictionary order.
+func (idx *Index) SearchEnglish(query string) []Ci {
+	idx.englishOnce.Do(idx.buildEnglish)
+
+	words := englishWords(query)
+	if len(words) == 0 {
+		return []Ci{}
+	}
+
+	ids := idx.byEnglish[words[0]]
+	for _, w := range words[1:] {
+		ids = intersectSorted(ids, idx.byEnglish[w])
+	}
+
+	phrase := strings.Join(words, " ")
+	exact := make([]Ci, 0)
+	rest := make([]Ci, 0, len(ids))
+	for _, i := range ids {
+		ci := idx.entries[i]
+		if glossIsPhrase(ci, phrase) {
+			exact = append(exact, ci)
+		} else {
+			rest = append(rest, ci)
+		}
+	}
+
+	return append(exact, rest...)
+}
+
+// glossIsPhrase reports whether a sense of ci has exactly the English words
+// of phrase, joined by spaces.
+func glossIsPhrase(ci Ci, phrase string) bool {
+	for _, g := range ci.Gloss {
+		if strings.Join(englishWords(g), " ") == phrase {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEnglishWords reports whether the gloss of ci contains every word of
+// words, as SearchEnglish matches entries without an index.
+func hasEnglishWords(ci Ci, words []string) bool {
+	found := make(map[string]bool)
+	for _, g := range ci.Gloss {
+		for _, w := range englishWords(g) {
+			found[w] = true
+		}
+	}
+	for _, w := range words {
+		if !found[w] {
+			return false
+		}
+	}
+	return true
+}
+
+func intersectSorted(a []int, b []int) []int {
+	out := make([]int, 0, min(len(a), len(b)))
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
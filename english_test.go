@@ -0,0 +1,51 @@
+package cccedictparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnglish(t *testing.T) {
+	tests := []testItem{
+		{Name: "english_Words", Test: english_Words},
+		{Name: "index_SearchEnglish", Test: index_SearchEnglish},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func english_Words(t *testing.T) {
+	cases := []testCase[string]{
+		{Sentence: "to be born under an unlucky star", Expected: "to,be,born,under,an,unlucky,star"},
+		{Sentence: "(of people) sturdy; tough", Expected: "of,people,sturdy,tough"},
+		{Sentence: "variant of 省[sheng3]", Expected: "variant,of"},
+		{Sentence: "CL:個|个[ge4]", Expected: ""},
+	}
+
+	for _, v := range cases {
+		if got := strings.Join(englishWords(v.Sentence), ","); got != v.Expected {
+			t.Errorf("words of %s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
+
+func index_SearchEnglish(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	cases := []testCase[string]{
+		{Sentence: "honey", Expected: "蜜,蜂蜜"},
+		{Sentence: "Restaurant", Expected: "餐馆,饭馆"},
+		{Sentence: "to study", Expected: "学,学习"},
+		{Sentence: "student", Expected: "学生,生,大学生"},
+		{Sentence: "ge", Expected: ""},
+		{Sentence: "", Expected: ""},
+	}
+
+	for _, v := range cases {
+		if got := strings.Join(headwords(idx.SearchEnglish(v.Sentence)), ","); got != v.Expected {
+			t.Errorf("search %s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
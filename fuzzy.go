@@ -0,0 +1,174 @@
+package cccedictparser
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// FuzzyMatch is an entry found through a vocabulary term close to the query.
+type FuzzyMatch struct {
+	Ci Ci
+	// Term is the English word or toneless pinyin key which matched.
+	Term     string
+	Distance int
+}
+
+// EditDistance returns the Damerau-Levenshtein distance (optimal string
+// alignment variant) between a and b, counted in characters: insertions,
+// deletions, substitutions and transpositions of adjacent characters all
+// cost 1.
+func EditDistance(a, b string) int {
+	return boundedEditDistance([]rune(a), []rune(b), -1)
+}
+
+// boundedEditDistance stops early and returns bound+1 once every alignment
+// costs more than bound. A negative bound disables it.
+func boundedEditDistance(a []rune, b []rune, bound int) int {
+	if bound >= 0 && abs(len(a)-len(b)) > bound {
+		return bound + 1
+	}
+
+	// three rows are enough for transpositions
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d := min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d = min(d, prev2[j-2]+1)
+			}
+			cur[j] = d
+			rowMin = min(rowMin, d)
+		}
+		if bound >= 0 && rowMin > bound {
+			return bound + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+
+	return prev[len(b)]
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+type fuzzyTerm struct {
+	term     string
+	distance int
+	ids      []int
+}
+
+// fuzzyTerms returns the vocabulary terms within maxDistance of query,
+// closest first and then by how many entries use them.
+func fuzzyTerms(vocabulary map[string][]int, query string, maxDistance int) []fuzzyTerm {
+	q := []rune(query)
+	out := make([]fuzzyTerm, 0)
+
+	for term, ids := range vocabulary {
+		if abs(utf8.RuneCountInString(term)-len(q)) > maxDistance {
+			continue
+		}
+		if d := boundedEditDistance(q, []rune(term), maxDistance); d <= maxDistance {
+			out = append(out, fuzzyTerm{term: term, distance: d, ids: ids})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].distance != out[j].distance {
+			return out[i].distance < out[j].distance
+		}
+		if len(out[i].ids) != len(out[j].ids) {
+			return len(out[i].ids) > len(out[j].ids)
+		}
+		return out[i].term < out[j].term
+	})
+
+	return out
+}
+
+func (idx *Index) fuzzyMatches(terms []fuzzyTerm) []FuzzyMatch {
+	seen := make(map[int]bool)
+	out := make([]FuzzyMatch, 0)
+	for _, t := range terms {
+		for _, i := range t.ids {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			out = append(out, FuzzyMatch{Ci: idx.entries[i], Term: t.term, Distance: t.distance})
+		}
+	}
+	return out
+}
+
+// FuzzyEnglish returns the entries whose gloss has a word within maxDistance
+// edits of word, closest first. "restaraunt" finds "restaurant".
+func (idx *Index) FuzzyEnglish(word string, maxDistance int) []FuzzyMatch {
+	idx.englishOnce.Do(idx.buildEnglish)
+	return idx.fuzzyMatches(fuzzyTerms(idx.byEnglish, strings.ToLower(strings.TrimSpace(word)), maxDistance))
+}
+
+// FuzzyPinyin returns the entries whose toneless pinyin is within maxDistance
+// edits of pinyin, closest first. Tone numbers and spaces in pinyin are
+// ignored, so "zhonguo" finds zhong1 guo2.
+func (idx *Index) FuzzyPinyin(pinyin string, maxDistance int) []FuzzyMatch {
+	return idx.fuzzyMatches(fuzzyTerms(idx.byToneless, normalizeTrieKey(pinyin), maxDistance))
+}
+
+// defaultMaxDistance allows one typo in short words and two in longer ones.
+func defaultMaxDistance(query string) int {
+	if utf8.RuneCountInString(query) <= 4 {
+		return 1
+	}
+	return 2
+}
+
+// DidYouMean suggests up to limit English words and toneless pinyin keys
+// close to query, for queries which found nothing. The query itself is never
+// suggested. A limit <= 0 returns every match.
+func (idx *Index) DidYouMean(query string, limit int) []string {
+	idx.englishOnce.Do(idx.buildEnglish)
+
+	english := strings.ToLower(strings.TrimSpace(query))
+	pinyin := normalizeTrieKey(query)
+
+	terms := fuzzyTerms(idx.byEnglish, english, defaultMaxDistance(english))
+	terms = append(terms, fuzzyTerms(idx.byToneless, pinyin, defaultMaxDistance(pinyin))...)
+
+	sort.SliceStable(terms, func(i, j int) bool {
+		if terms[i].distance != terms[j].distance {
+			return terms[i].distance < terms[j].distance
+		}
+		return len(terms[i].ids) > len(terms[j].ids)
+	})
+
+	seen := map[string]bool{english: true, pinyin: true}
+	out := make([]string, 0)
+	for _, t := range terms {
+		if seen[t.term] {
+			continue
+		}
+		seen[t.term] = true
+		out = append(out, t.term)
+		if len(out) == limit {
+			break
+		}
+	}
+	return out
+}
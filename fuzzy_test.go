@@ -0,0 +1,112 @@
+package cccedictparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFuzzy(t *testing.T) {
+	tests := []testItem{
+		{Name: "fuzzy_EditDistance", Test: fuzzy_EditDistance},
+		{Name: "fuzzy_BoundedEditDistance", Test: fuzzy_BoundedEditDistance},
+		{Name: "index_FuzzyEnglish", Test: index_FuzzyEnglish},
+		{Name: "index_FuzzyPinyin", Test: index_FuzzyPinyin},
+		{Name: "index_DidYouMean", Test: index_DidYouMean},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func fuzzy_EditDistance(t *testing.T) {
+	cases := []testCase[int]{
+		{Sentence: "restaurant|restaraunt", Expected: 2},
+		{Sentence: "zhongguo|zhonguo", Expected: 1},
+		{Sentence: "abc|acb", Expected: 1},
+		{Sentence: "|abc", Expected: 3},
+		{Sentence: "中国|中國", Expected: 1},
+		{Sentence: "same|same", Expected: 0},
+	}
+
+	for _, v := range cases {
+		a, b, _ := strings.Cut(v.Sentence, "|")
+		if got := EditDistance(a, b); got != v.Expected {
+			t.Errorf("distance %s/%s: expected %d, got %d", a, b, v.Expected, got)
+		}
+		if got := EditDistance(b, a); got != v.Expected {
+			t.Errorf("distance %s/%s: expected %d, got %d", b, a, v.Expected, got)
+		}
+	}
+}
+
+func fuzzy_BoundedEditDistance(t *testing.T) {
+	if got := boundedEditDistance([]rune("kitten"), []rune("sitting"), 1); got != 2 {
+		t.Errorf("expected bound+1 (2), got %d", got)
+	}
+	if got := boundedEditDistance([]rune("kitten"), []rune("sitting"), 3); got != 3 {
+		t.Errorf("expected 3, got %d", got)
+	}
+}
+
+func fuzzyHeadwords(ms []FuzzyMatch) string {
+	out := make([]string, 0, len(ms))
+	for _, v := range ms {
+		out = append(out, v.Ci.Jiantizi)
+	}
+	return strings.Join(out, ",")
+}
+
+func index_FuzzyEnglish(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	got := idx.FuzzyEnglish("restaraunt", 2)
+	if fuzzyHeadwords(got) != "餐馆,饭馆" {
+		t.Fatalf("expected 餐馆,饭馆 got %s", fuzzyHeadwords(got))
+	}
+	if got[0].Term != "restaurant" || got[0].Distance != 2 {
+		t.Errorf("expected match through restaurant at distance 2, got %s at %d", got[0].Term, got[0].Distance)
+	}
+
+	if got := idx.FuzzyEnglish("restaraunt", 1); len(got) != 0 {
+		t.Errorf("expected nothing within distance 1, got %s", fuzzyHeadwords(got))
+	}
+}
+
+func index_FuzzyPinyin(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	got := idx.FuzzyPinyin("zhonguo", 1)
+	if fuzzyHeadwords(got) != "中国" {
+		t.Errorf("expected 中国, got %s", fuzzyHeadwords(got))
+	}
+
+	got = idx.FuzzyPinyin("laohu3", 0)
+	if fuzzyHeadwords(got) != "老虎" {
+		t.Errorf("expected 老虎, got %s", fuzzyHeadwords(got))
+	}
+}
+
+func index_DidYouMean(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	cases := []struct {
+		query    string
+		limit    int
+		expected []string
+	}{
+		{query: "restaraunt", limit: 1, expected: []string{"restaurant"}},
+		{query: "zhonguo", limit: 1, expected: []string{"zhongguo"}},
+		{query: "tigr", limit: 1, expected: []string{"tiger"}},
+		{query: "zhonguo", limit: 0, expected: []string{"zhongguo", "zhong"}},
+		{query: "zhonguo", limit: -1, expected: []string{"zhongguo", "zhong"}},
+		{query: "zhonguo", limit: 5, expected: []string{"zhongguo", "zhong"}},
+	}
+
+	for _, v := range cases {
+		got := idx.DidYouMean(v.query, v.limit)
+		if strings.Join(got, ",") != strings.Join(v.expected, ",") {
+			t.Errorf("did you mean %s, limit %d: expected %v, got %v", v.query, v.limit, v.expected, got)
+		}
+	}
+}
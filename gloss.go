@@ -0,0 +1,216 @@
+package cccedictparser
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// usage and register markers written in parentheses inside glosses, mapped
+// to tag names
+var gloss_tag_markers = map[string]string{
+	"coll.":          "coll",
+	"colloquial":     "coll",
+	"idiom":          "idiom",
+	"proverb":        "proverb",
+	"slang":          "slang",
+	"internet slang": "internet",
+	"dialect":        "dialect",
+	"literary":       "literary",
+	"classical":      "classical",
+	"old":            "old",
+	"archaic":        "archaic",
+	"onom.":          "onom",
+	"loanword":       "loanword",
+	"tw":             "Tw",
+	"taiwan":         "Tw",
+	"vulgar":         "vulgar",
+	"derog.":         "derog",
+	"polite":         "polite",
+	"honorific":      "honorific",
+	"humble":         "humble",
+	"abbr.":          "abbr",
+	"euphemism":      "euph",
+	"cantonese":      "Cant",
+	"buddhism":       "Buddhism",
+}
+
+// description of every tag name
+var gloss_tag_notes = map[string]string{
+	"coll":      "colloquial",
+	"idiom":     "idiom (chengyu)",
+	"proverb":   "proverb",
+	"slang":     "slang",
+	"internet":  "Internet slang",
+	"dialect":   "dialect",
+	"literary":  "literary",
+	"classical": "classical Chinese",
+	"old":       "old usage",
+	"archaic":   "archaic",
+	"onom":      "onomatopoeia",
+	"loanword":  "loanword",
+	"Tw":        "used in Taiwan",
+	"vulgar":    "vulgar",
+	"derog":     "derogatory",
+	"polite":    "polite",
+	"honorific": "honorific",
+	"humble":    "humble",
+	"abbr":      "abbreviation",
+	"euph":      "euphemism",
+	"Cant":      "Cantonese",
+	"Buddhism":  "Buddhism",
+}
+
+// GlossTags returns the usage tags marked in a gloss, e.g. "coll" for
+// "(coll.) brothers" and "idiom" for "each in the correct place (idiom)".
+func GlossTags(gloss string) []string {
+	var tags []string
+	rest := gloss
+	for {
+		start := strings.IndexByte(rest, '(')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], ')')
+		if end < 0 {
+			break
+		}
+
+		inner := strings.ToLower(strings.TrimSpace(rest[start+1 : start+end]))
+		// "(idiom, coll.)" carries two markers
+		for _, part := range strings.Split(inner, ",") {
+			if tag, ok := gloss_tag_markers[strings.TrimSpace(part)]; ok && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		rest = rest[start+end+1:]
+	}
+	return tags
+}
+
+// Tags returns the usage tags marked in any sense of ci, in order of first
+// appearance.
+func (ci Ci) Tags() []string {
+	var tags []string
+	for _, g := range ci.Gloss {
+		for _, tag := range GlossTags(g) {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// GlossReference is a word referred to in a gloss, written "trad|simp[pinyin]"
+// or "simp[pinyin]", e.g. 陝西省|陕西省[Shan3 xi1 Sheng3].
+type GlossReference struct {
+	Fantizi   string
+	Jiantizi  string
+	Pinyin    []PinyinV2
+	PinyinRaw string
+	// Start and End are the byte offsets of the reference in the gloss.
+	Start int
+	End   int
+}
+
+// isReferenceRune reports whether r can be part of the hanzi of a reference.
+func isReferenceRune(r rune) bool {
+	return unicode.Is(unicode.Han, r) || r == '|' || r == '·' || r == '〇'
+}
+
+// isReferencePinyin reports whether py reads as the pinyin of a word: toned
+// syllables and single letters, as in 卡拉OK[ka3 la1 O K].
+func isReferencePinyin(py []PinyinV2) bool {
+	for _, w := range py {
+		for _, p := range w.Word {
+			if p.Type != Normal && !(p.Type == Alphabet && len(p.Sound) == 1) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// GlossReferences returns the words referred to in a gloss, in order.
+// Brackets which are not preceded by hanzi or do not hold cc-cedict pinyin
+// are not references.
+func GlossReferences(gloss string) []GlossReference {
+	var out []GlossReference
+	for i := 0; i < len(gloss); i++ {
+		if gloss[i] != '[' {
+			continue
+		}
+		end := strings.IndexByte(gloss[i:], ']')
+		if end < 0 {
+			break
+		}
+		end += i
+
+		start := i
+		for start > 0 {
+			r, size := utf8.DecodeLastRuneInString(gloss[:start])
+			if !isReferenceRune(r) {
+				break
+			}
+			start -= size
+		}
+
+		hanzi := strings.Trim(gloss[start:i], "|")
+		raw := gloss[i+1 : end]
+		py, err := pinyinV1StrToPinyin(raw)
+		if hanzi == "" || raw == "" || err != nil || !isReferencePinyin(py) {
+			continue
+		}
+
+		trad, simp, ok := strings.Cut(hanzi, "|")
+		if !ok {
+			simp = trad
+		}
+		out = append(out, GlossReference{
+			Fantizi:   trad,
+			Jiantizi:  simp,
+			Pinyin:    py,
+			PinyinRaw: raw,
+			Start:     start,
+			End:       end + 1,
+		})
+		i = end
+	}
+	return out
+}
+
+// Classifier is a measure word listed in a "CL:" gloss, e.g. 個|个[ge4].
+type Classifier struct {
+	Fantizi   string
+	Jiantizi  string
+	Pinyin    []PinyinV2
+	PinyinRaw string
+}
+
+// Classifiers returns the measure words of the "CL:" senses of ci, e.g. 个
+// and 位 for "CL:個|个[ge4],位[wei4]". Items which cannot be read are skipped.
+func (ci Ci) Classifiers() []Classifier {
+	var out []Classifier
+	for _, g := range ci.Gloss {
+		if !strings.HasPrefix(g, "CL:") {
+			continue
+		}
+		for _, ref := range GlossReferences(g) {
+			out = append(out, Classifier{Fantizi: ref.Fantizi, Jiantizi: ref.Jiantizi, Pinyin: ref.Pinyin, PinyinRaw: ref.PinyinRaw})
+		}
+	}
+	return out
+}
+
+// Senses returns the gloss of ci without its "CL:" senses.
+func (ci Ci) Senses() []string {
+	out := make([]string, 0, len(ci.Gloss))
+	for _, g := range ci.Gloss {
+		if !strings.HasPrefix(g, "CL:") {
+			out = append(out, g)
+		}
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package cccedictparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGloss(t *testing.T) {
+	tests := []testItem{
+		{Name: "gloss_Tags", Test: gloss_Tags},
+		{Name: "gloss_CiTags", Test: gloss_CiTags},
+		{Name: "gloss_Classifiers", Test: gloss_Classifiers},
+		{Name: "gloss_References", Test: gloss_References},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func gloss_Tags(t *testing.T) {
+	cases := []testCase[string]{
+		{Sentence: "(coll.) brothers", Expected: "coll"},
+		{Sentence: "(idiom) each in the correct place; each is provided for", Expected: "idiom"},
+		{Sentence: "lit. draw legs on a snake (idiom); fig. to ruin the effect", Expected: "idiom"},
+		{Sentence: "(Tw) (slang) awesome", Expected: "Tw,slang"},
+		{Sentence: "(idiom, coll.) to do sth", Expected: "idiom,coll"},
+		{Sentence: "(of a feeling) to show on the face", Expected: ""},
+		{Sentence: "(unclosed", Expected: ""},
+	}
+
+	for _, v := range cases {
+		if got := strings.Join(GlossTags(v.Sentence), ","); got != v.Expected {
+			t.Errorf("tags of %s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
+
+func gloss_CiTags(t *testing.T) {
+	ci, err := ParseLine("給力 给力 [gei3 li4] /(slang) cool/(slang) awesome/(dialect) to give it all one has/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(ci.Tags(), ","); got != "slang,dialect" {
+		t.Errorf("expected slang,dialect got %s", got)
+	}
+}
+
+func gloss_Classifiers(t *testing.T) {
+	cases := []testCase[string]{
+		{Sentence: "人 人 [ren2] /person/people/CL:個|个[ge4],位[wei4]/", Expected: "個 个 ge4;位 位 wei4|person,people"},
+		{Sentence: "事 事 [shi4] /matter/CL:件[jian4],樁|桩[zhuang1]/", Expected: "件 件 jian4;樁 桩 zhuang1|matter"},
+		{Sentence: "你好 你好 [ni3 hao3] /hello/hi/", Expected: "|hello,hi"},
+		{Sentence: "字 字 [zi4] /word/CL:個|个[ge4],broken/", Expected: "個 个 ge4|word"},
+	}
+
+	for _, v := range cases {
+		ci, err := ParseLine(v.Sentence)
+		if err != nil {
+			t.Errorf("error: %s. Line %s", err.Error(), v.Sentence)
+			continue
+		}
+
+		var cls []string
+		for _, c := range ci.Classifiers() {
+			cls = append(cls, c.Fantizi+" "+c.Jiantizi+" "+c.PinyinRaw)
+		}
+		if got := strings.Join(cls, ";") + "|" + strings.Join(ci.Senses(), ","); got != v.Expected {
+			t.Errorf("%s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
+
+func gloss_References(t *testing.T) {
+	cases := []testCase[string]{
+		{Sentence: "capital of Shaanxi 陝西省|陕西省[Shan3 xi1 Sheng3] in northwest China", Expected: "陝西省 陕西省 Shan3 xi1 Sheng3 陝西省|陕西省[Shan3 xi1 Sheng3]"},
+		{Sentence: "eldest (as in 大姐[da4 jie3])", Expected: "大姐 大姐 da4 jie3 大姐[da4 jie3]"},
+		{Sentence: "you (informal, as opposed to courteous 您[nin2])", Expected: "您 您 nin2 您[nin2]"},
+		{Sentence: "see also 吃飯|吃饭[chi1 fan4] and 喝[he1]", Expected: "吃飯 吃饭 chi1 fan4 吃飯|吃饭[chi1 fan4];喝 喝 he1 喝[he1]"},
+		{Sentence: "abbr. for [something]", Expected: ""},
+		{Sentence: "Beijing 北京[not pinyin]", Expected: ""},
+	}
+
+	for _, v := range cases {
+		var refs []string
+		for _, r := range GlossReferences(v.Sentence) {
+			refs = append(refs, r.Fantizi+" "+r.Jiantizi+" "+r.PinyinRaw+" "+v.Sentence[r.Start:r.End])
+		}
+		if got := strings.Join(refs, ";"); got != v.Expected {
+			t.Errorf("%s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package cccedictparser
+
+import (
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// hwPosting records that a character occurs in a headword at pos (counted
+// in characters from the start). length is the headword length in characters.
+type hwPosting struct {
+	headword int32
+	pos      uint16
+	length   uint16
+}
+
+// headwordIndex maps every character to the headwords it appears in, so
+// pattern queries only verify headwords sharing a character with the pattern.
+type headwordIndex struct {
+	// distinct simplified and traditional headwords
+	words []string
+	// entries for every word
+	entries  [][]int
+	postings map[rune][]hwPosting
+}
+
+func (idx *Index) buildHeadwords() {
+	hi := &headwordIndex{postings: make(map[rune][]hwPosting)}
+	ids := make(map[string]int32)
+
+	add := func(word string, entry int) {
+		id, ok := ids[word]
+		if !ok {
+			id = int32(len(hi.words))
+			ids[word] = id
+			hi.words = append(hi.words, word)
+			hi.entries = append(hi.entries, nil)
+
+			length := uint16(utf8.RuneCountInString(word))
+			pos := uint16(0)
+			for _, r := range word {
+				hi.postings[r] = append(hi.postings[r], hwPosting{headword: id, pos: pos, length: length})
+				pos++
+			}
+		}
+		if n := len(hi.entries[id]); n == 0 || hi.entries[id][n-1] != entry {
+			hi.entries[id] = append(hi.entries[id], entry)
+		}
+	}
+
+	for i, ci := range idx.entries {
+		add(ci.Jiantizi, i)
+		add(ci.Fantizi, i)
+	}
+
+	idx.headwords = hi
+}
+
+// entriesFor returns the entries of the given headwords without duplicates,
+// in dictionary order.
+func (idx *Index) entriesFor(words []int32) []Ci {
+	seen := make(map[int]bool)
+	ids := make([]int, 0, len(words))
+	for _, w := range words {
+		for _, e := range idx.headwords.entries[w] {
+			if !seen[e] {
+				seen[e] = true
+				ids = append(ids, e)
+			}
+		}
+	}
+	sort.Ints(ids)
+
+	out := make([]Ci, 0, len(ids))
+	for _, e := range ids {
+		out = append(out, idx.entries[e])
+	}
+	return out
+}
+
+// globMatch reports whether word matches a pattern where ? is any single
+// character and * any run of characters.
+func globMatch(pattern []rune, word []rune) bool {
+	p, w := 0, 0
+	star, mark := -1, 0
+	for w < len(word) {
+		if p < len(pattern) && (pattern[p] == '?' || pattern[p] == word[w]) {
+			p++
+			w++
+		} else if p < len(pattern) && pattern[p] == '*' {
+			star = p
+			mark = w
+			p++
+		} else if star != -1 {
+			p = star + 1
+			mark++
+			w = mark
+		} else {
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// globConstraint is what a single literal of a pattern says about where it
+// occurs in a matching headword.
+type globConstraint struct {
+	char rune
+	// fromStart/fromEnd are the anchored position, -1 when floating
+	fromStart int
+	fromEnd   int
+}
+
+func (c globConstraint) accepts(p hwPosting, minLen int, fixedLen bool) bool {
+	length := int(p.length)
+	if length < minLen || (fixedLen && length != minLen) {
+		return false
+	}
+	if c.fromStart >= 0 && int(p.pos) != c.fromStart {
+		return false
+	}
+	if c.fromEnd >= 0 && length-1-int(p.pos) != c.fromEnd {
+		return false
+	}
+	return true
+}
+
+// Match returns the entries whose simplified or traditional headword matches
+// pattern, where ? stands for any single character and * for any run of
+// characters: "?国" is any two character word ending in 国, "*心*" any word
+// containing 心. The match covers the whole headword.
+func (idx *Index) Match(pattern string) []Ci {
+	idx.headwordOnce.Do(idx.buildHeadwords)
+	hi := idx.headwords
+
+	pr := []rune(pattern)
+	firstStar, lastStar := -1, -1
+	minLen := 0
+	for i, r := range pr {
+		if r == '*' {
+			if firstStar < 0 {
+				firstStar = i
+			}
+			lastStar = i
+		} else {
+			minLen++
+		}
+	}
+	fixedLen := firstStar < 0
+
+	var best *globConstraint
+	for i, r := range pr {
+		if r == '*' || r == '?' {
+			continue
+		}
+		c := globConstraint{char: r, fromStart: -1, fromEnd: -1}
+		if fixedLen || i < firstStar {
+			c.fromStart = i
+		} else if i > lastStar {
+			c.fromEnd = len(pr) - 1 - i
+		}
+		if best == nil || len(hi.postings[r]) < len(hi.postings[best.char]) {
+			best = &c
+		}
+	}
+
+	var words []int32
+	if best == nil {
+		// nothing but wildcards, every headword is a candidate
+		for i, w := range hi.words {
+			n := utf8.RuneCountInString(w)
+			if n == minLen || (!fixedLen && n > minLen) {
+				words = append(words, int32(i))
+			}
+		}
+		return idx.entriesFor(words)
+	}
+
+	seen := make(map[int32]bool)
+	for _, p := range hi.postings[best.char] {
+		if seen[p.headword] || !best.accepts(p, minLen, fixedLen) {
+			continue
+		}
+		seen[p.headword] = true
+		if globMatch(pr, []rune(hi.words[p.headword])) {
+			words = append(words, p.headword)
+		}
+	}
+
+	return idx.entriesFor(words)
+}
+
+// MatchRegexp returns the entries whose simplified or traditional headword
+// matches re. As with regexp.MatchString the match may be anywhere in the
+// headword; anchor the expression with ^ and $ to match whole words.
+func (idx *Index) MatchRegexp(re *regexp.Regexp) []Ci {
+	idx.headwordOnce.Do(idx.buildHeadwords)
+	hi := idx.headwords
+
+	var words []int32
+	if prefix, _ := re.LiteralPrefix(); prefix != "" {
+		// every match contains the literal prefix, so only headwords
+		// holding its first character are candidates
+		r, _ := utf8.DecodeRuneInString(prefix)
+		seen := make(map[int32]bool)
+		for _, p := range hi.postings[r] {
+			if seen[p.headword] {
+				continue
+			}
+			seen[p.headword] = true
+			if re.MatchString(hi.words[p.headword]) {
+				words = append(words, p.headword)
+			}
+		}
+	} else {
+		for i, w := range hi.words {
+			if re.MatchString(w) {
+				words = append(words, int32(i))
+			}
+		}
+	}
+
+	return idx.entriesFor(words)
+}
+
+func (idx *Index) charAt(r rune, fromEnd bool) []Ci {
+	idx.headwordOnce.Do(idx.buildHeadwords)
+
+	var words []int32
+	for _, p := range idx.headwords.postings[r] {
+		pos := int(p.pos)
+		if fromEnd {
+			pos = int(p.length) - 1 - pos
+		}
+		if pos == 0 {
+			words = append(words, p.headword)
+		}
+	}
+	return idx.entriesFor(words)
+}
+
+// StartsWith returns the entries whose simplified or traditional headword
+// starts with r.
+func (idx *Index) StartsWith(r rune) []Ci {
+	return idx.charAt(r, false)
+}
+
+// EndsWith returns the entries whose simplified or traditional headword ends
+// with r.
+func (idx *Index) EndsWith(r rune) []Ci {
+	return idx.charAt(r, true)
+}
@@ -0,0 +1,93 @@
+package cccedictparser
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestHeadword(t *testing.T) {
+	tests := []testItem{
+		{Name: "headword_GlobMatch", Test: headword_GlobMatch},
+		{Name: "index_Match", Test: index_Match},
+		{Name: "index_MatchRegexp", Test: index_MatchRegexp},
+		{Name: "index_StartsWithEndsWith", Test: index_StartsWithEndsWith},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func headword_GlobMatch(t *testing.T) {
+	cases := []testCase[bool]{
+		{Sentence: "?国|中国", Expected: true},
+		{Sentence: "?国|中国人", Expected: false},
+		{Sentence: "*国*|中国人", Expected: true},
+		{Sentence: "*|", Expected: true},
+		{Sentence: "大*生|大学生", Expected: true},
+		{Sentence: "大*生|大学", Expected: false},
+		{Sentence: "??|中", Expected: false},
+	}
+
+	for _, v := range cases {
+		pattern, word, _ := strings.Cut(v.Sentence, "|")
+		if got := globMatch([]rune(pattern), []rune(word)); got != v.Expected {
+			t.Errorf("glob %s against %s: expected %t, got %t", pattern, word, v.Expected, got)
+		}
+	}
+}
+
+func index_Match(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	cases := []testCase[string]{
+		{Sentence: "?国", Expected: "中国"},
+		{Sentence: "?國", Expected: "中国"},
+		{Sentence: "*心*", Expected: "一心一意"},
+		{Sentence: "大*", Expected: "大,大学,大学生"},
+		{Sentence: "*学", Expected: "大学,学"},
+		{Sentence: "学?", Expected: "学习,学生"},
+		{Sentence: "*館", Expected: "餐馆,饭馆"},
+		{Sentence: "????", Expected: "一心一意,卡拉OK,各得其所,画蛇添足,见利忘义"},
+		{Sentence: "中?人", Expected: "中国人"},
+		{Sentence: "国", Expected: "国"},
+		{Sentence: "?国?", Expected: "中国人"},
+		{Sentence: "不*", Expected: ""},
+	}
+
+	for _, v := range cases {
+		if got := sortedHeadwords(idx.Match(v.Sentence)); got != v.Expected {
+			t.Errorf("match %s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
+
+func index_MatchRegexp(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	cases := []testCase[string]{
+		{Sentence: "^大学", Expected: "大学,大学生"},
+		{Sentence: "^.们$", Expected: "你们,我们"},
+		{Sentence: "[鼠虎]$", Expected: "老虎,老鼠"},
+		{Sentence: "OK", Expected: "卡拉OK"},
+	}
+
+	for _, v := range cases {
+		if got := sortedHeadwords(idx.MatchRegexp(regexp.MustCompile(v.Sentence))); got != v.Expected {
+			t.Errorf("regexp %s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
+
+func index_StartsWithEndsWith(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	if got := sortedHeadwords(idx.StartsWith('老')); got != "老,老虎,老鼠" {
+		t.Errorf("starts with 老: got %s", got)
+	}
+
+	if got := sortedHeadwords(idx.EndsWith('們')); got != "们,你们,我们" {
+		t.Errorf("ends with 們: got %s", got)
+	}
+}
@@ -0,0 +1,226 @@
+package cccedictparser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Loaded is one version of a dictionary file held by a Holder.
+type Loaded struct {
+	Index *Index
+	// ModTime and Size are those of the file when it was read.
+	ModTime time.Time
+	Size    int64
+	// LoadedAt is when the version was swapped in.
+	LoadedAt time.Time
+	// Generation counts the versions loaded, starting at 1.
+	Generation int
+	// LineErrors are the lines which failed to parse and were left out.
+	LineErrors []*LineError
+}
+
+// HolderOptions configures the checks a new version of the dictionary must
+// pass before it replaces the current one.
+type HolderOptions struct {
+	// MaxLineErrors is the number of lines which may fail to parse. Zero
+	// allows none, a negative value any number.
+	MaxLineErrors int
+	// MinRatio rejects a version with fewer entries than MinRatio times
+	// those of the current version, e.g. 0.9 catches a truncated file.
+	// Zero disables the check.
+	MinRatio float64
+	// Validate, if set, is called with the new index and rejects it by
+	// returning an error.
+	Validate func(idx *Index) error
+	// OnReload, if set, is called after every reload attempt, with the new
+	// version or with the error which kept the current one.
+	OnReload func(l *Loaded, err error)
+}
+
+// Holder holds the current version of a dictionary file for long-running
+// services and replaces it when the file changes. Readers get the current
+// version with a single atomic load and are never blocked by a reload: a
+// new version is parsed and checked on the side, then swapped in. When it
+// fails its checks the current version keeps being served.
+//
+// Replace the file by renaming a new one over it, so a reload never reads a
+// partly written file.
+type Holder struct {
+	path    string
+	opts    HolderOptions
+	current atomic.Pointer[Loaded]
+
+	// serializes reloads, readers never take it
+	reloadMu sync.Mutex
+	// file of the last failed reload, not retried until it changes
+	failedModTime time.Time
+	failedSize    int64
+}
+
+// NewHolder loads the dictionary file at path. It fails if the file cannot
+// be read or does not pass the checks of opts.
+func NewHolder(path string, opts HolderOptions) (*Holder, error) {
+	h := &Holder{path: path, opts: opts}
+	if err := h.Reload(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Current returns the current version.
+func (h *Holder) Current() *Loaded {
+	return h.current.Load()
+}
+
+// Index returns the index of the current version. Callers which make several
+// lookups for one request should keep the returned index rather than call
+// Index again, so they see a single version.
+func (h *Holder) Index() *Index {
+	return h.current.Load().Index
+}
+
+// load reads and checks a new version of the file.
+func (h *Holder) load(prev *Loaded) (*Loaded, error) {
+	f, err := os.Open(h.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Loaded{ModTime: info.ModTime(), Size: info.Size()}
+
+	entries, err := LoadDictionary(context.Background(), f, LoadOptions{})
+	if err != nil {
+		var joined interface{ Unwrap() []error }
+		errs := []error{err}
+		if errors.As(err, &joined) {
+			errs = joined.Unwrap()
+		}
+		for _, e := range errs {
+			var lineErr *LineError
+			if !errors.As(e, &lineErr) {
+				return l, e
+			}
+			l.LineErrors = append(l.LineErrors, lineErr)
+		}
+	}
+
+	if h.opts.MaxLineErrors >= 0 && len(l.LineErrors) > h.opts.MaxLineErrors {
+		return l, fmt.Errorf("%s: %d lines failed to parse, at most %d allowed, first %w", h.path, len(l.LineErrors), h.opts.MaxLineErrors, l.LineErrors[0])
+	}
+	if len(entries) == 0 {
+		return l, fmt.Errorf("%s: no entries", h.path)
+	}
+	if prev != nil && h.opts.MinRatio > 0 && float64(len(entries)) < h.opts.MinRatio*float64(prev.Index.Len()) {
+		return l, fmt.Errorf("%s: %d entries, fewer than %.0f%% of the %d loaded", h.path, len(entries), h.opts.MinRatio*100, prev.Index.Len())
+	}
+
+	l.Index = NewIndex(entries)
+	if h.opts.Validate != nil {
+		if err := h.opts.Validate(l.Index); err != nil {
+			return l, fmt.Errorf("%s: %w", h.path, err)
+		}
+	}
+	return l, nil
+}
+
+// Reload reads the file again and swaps the new version in if it passes the
+// checks. On error the current version is kept.
+func (h *Holder) Reload() error {
+	h.reloadMu.Lock()
+	defer h.reloadMu.Unlock()
+
+	prev := h.current.Load()
+	l, err := h.load(prev)
+	if err != nil {
+		if l != nil {
+			h.failedModTime, h.failedSize = l.ModTime, l.Size
+		}
+		if h.opts.OnReload != nil {
+			h.opts.OnReload(nil, err)
+		}
+		return err
+	}
+
+	l.LoadedAt = time.Now()
+	l.Generation = 1
+	if prev != nil {
+		l.Generation = prev.Generation + 1
+	}
+	h.current.Store(l)
+	h.failedModTime, h.failedSize = time.Time{}, 0
+
+	if h.opts.OnReload != nil {
+		h.opts.OnReload(l, nil)
+	}
+	return nil
+}
+
+// ReloadIfChanged reloads the file if its modification time or size differ
+// from the current version, and reports whether a new version was swapped
+// in. A file which failed to load is not tried again until it changes.
+func (h *Holder) ReloadIfChanged() (bool, error) {
+	info, err := os.Stat(h.path)
+	if err != nil {
+		return false, err
+	}
+
+	h.reloadMu.Lock()
+	cur := h.current.Load()
+	unchanged := cur != nil && info.ModTime().Equal(cur.ModTime) && info.Size() == cur.Size
+	failed := info.ModTime().Equal(h.failedModTime) && info.Size() == h.failedSize
+	h.reloadMu.Unlock()
+
+	if unchanged || failed {
+		return false, nil
+	}
+	if err := h.Reload(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Poll checks the file every interval and reloads it when it changed, until
+// ctx is done. Errors are reported to HolderOptions.OnReload.
+func (h *Holder) Poll(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			h.ReloadIfChanged()
+		}
+	}
+}
+
+// ReloadOnSignal reloads the file whenever one of sigs is received, e.g.
+// syscall.SIGHUP, until ctx is done. Errors are reported to
+// HolderOptions.OnReload.
+func (h *Holder) ReloadOnSignal(ctx context.Context, sigs ...os.Signal) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sigs...)
+	defer signal.Stop(c)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c:
+			h.Reload()
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package cccedictparser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHolder(t *testing.T) {
+	tests := []testItem{
+		{Name: "holder_Reload", Test: holder_Reload},
+		{Name: "holder_KeepsCurrentOnFailure", Test: holder_KeepsCurrentOnFailure},
+		{Name: "holder_ReloadIfChanged", Test: holder_ReloadIfChanged},
+		{Name: "holder_ConcurrentReaders", Test: holder_ConcurrentReaders},
+		{Name: "holder_Poll", Test: holder_Poll},
+		{Name: "holder_Signal", Test: holder_Signal},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+const holder_v1 = "中國 中国 [Zhong1 guo2] /China/\n好 好 [hao3] /good/\n"
+const holder_v2 = "中國 中国 [Zhong1 guo2] /China/\n好 好 [hao3] /good/\n你好 你好 [ni3 hao3] /hello/\n"
+
+// replaceFile writes data to path by renaming a new file over it, with a
+// distinct modification time.
+func replaceFile(t *testing.T, path string, data string, mtime time.Time) {
+	t.Helper()
+	tmp := path + ".new"
+	if err := os.WriteFile(tmp, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(tmp, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestHolder(t *testing.T, opts HolderOptions) (*Holder, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cedict.u8")
+	replaceFile(t, path, holder_v1, time.Unix(1000, 0))
+	h, err := NewHolder(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h, path
+}
+
+func holder_Reload(t *testing.T) {
+	var reloads []int
+	h, path := newTestHolder(t, HolderOptions{OnReload: func(l *Loaded, err error) {
+		if err == nil {
+			reloads = append(reloads, l.Generation)
+		}
+	}})
+
+	old := h.Current()
+	if old.Generation != 1 || old.Index.Len() != 2 || len(h.Index().Lookup("你好")) != 0 {
+		t.Fatalf("unexpected first version %+v", old)
+	}
+
+	replaceFile(t, path, holder_v2, time.Unix(2000, 0))
+	if err := h.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	cur := h.Current()
+	if cur.Generation != 2 || cur.Index.Len() != 3 || len(h.Index().Lookup("你好")) != 1 || !cur.ModTime.Equal(time.Unix(2000, 0)) {
+		t.Errorf("unexpected second version %+v", cur)
+	}
+	// the old version is unchanged for readers still holding it
+	if old.Index.Len() != 2 {
+		t.Errorf("old version modified")
+	}
+	if len(reloads) != 2 || reloads[1] != 2 {
+		t.Errorf("unexpected reload notifications %v", reloads)
+	}
+}
+
+func holder_KeepsCurrentOnFailure(t *testing.T) {
+	validateErr := errors.New("missing 好")
+	h, path := newTestHolder(t, HolderOptions{
+		MinRatio: 0.9,
+		Validate: func(idx *Index) error {
+			if len(idx.Lookup("好")) == 0 {
+				return validateErr
+			}
+			return nil
+		},
+	})
+
+	cases := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"parse error", holder_v2 + "broken line\n", "1 lines failed to parse"},
+		{"empty", "# nothing\n", "no entries"},
+		{"truncated", "好 好 [hao3] /good/\n", "fewer than 90%"},
+		{"validation", "中國 中国 [Zhong1 guo2] /China/\n你好 你好 [ni3 hao3] /hello/\n", "missing 好"},
+	}
+
+	for i, v := range cases {
+		replaceFile(t, path, v.data, time.Unix(int64(3000+i), 0))
+		err := h.Reload()
+		if err == nil || !strings.Contains(err.Error(), v.err) {
+			t.Errorf("%s: expected error %q, got %v", v.name, v.err, err)
+		}
+		if cur := h.Current(); cur.Generation != 1 || cur.Index.Len() != 2 {
+			t.Errorf("%s: current version replaced", v.name)
+		}
+	}
+
+	var lineErr *LineError
+	replaceFile(t, path, holder_v2+"broken line\n", time.Unix(4000, 0))
+	if err := h.Reload(); !errors.As(err, &lineErr) || lineErr.Line != 4 {
+		t.Errorf("expected a line error on line 4, got %v", err)
+	}
+
+	os.Remove(path)
+	if err := h.Reload(); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+	if h.Current().Generation != 1 {
+		t.Errorf("current version replaced")
+	}
+}
+
+func holder_ReloadIfChanged(t *testing.T) {
+	failures := 0
+	h, path := newTestHolder(t, HolderOptions{OnReload: func(l *Loaded, err error) {
+		if err != nil {
+			failures++
+		}
+	}})
+
+	if changed, err := h.ReloadIfChanged(); changed || err != nil {
+		t.Errorf("unchanged file reloaded: %v %v", changed, err)
+	}
+
+	replaceFile(t, path, holder_v2, time.Unix(2000, 0))
+	if changed, err := h.ReloadIfChanged(); !changed || err != nil || h.Current().Generation != 2 {
+		t.Errorf("changed file not reloaded: %v %v", changed, err)
+	}
+
+	// a broken file is tried once
+	replaceFile(t, path, "broken line\n", time.Unix(3000, 0))
+	if _, err := h.ReloadIfChanged(); err == nil {
+		t.Errorf("expected an error")
+	}
+	if changed, err := h.ReloadIfChanged(); changed || err != nil || failures != 1 {
+		t.Errorf("broken file retried: %v %v, %d failures", changed, err, failures)
+	}
+
+	replaceFile(t, path, holder_v2, time.Unix(4000, 0))
+	if changed, err := h.ReloadIfChanged(); !changed || err != nil || h.Current().Generation != 3 {
+		t.Errorf("fixed file not reloaded: %v %v", changed, err)
+	}
+}
+
+func holder_ConcurrentReaders(t *testing.T) {
+	h, path := newTestHolder(t, HolderOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				idx := h.Index()
+				// every version has 中国, and one version is read consistently
+				if len(idx.Lookup("中国")) != 1 || (idx.Len() == 3) != (len(idx.Lookup("你好")) == 1) {
+					t.Errorf("inconsistent version")
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		data := holder_v1
+		if i%2 == 0 {
+			data = holder_v2
+		}
+		replaceFile(t, path, data, time.Unix(int64(2000+i), 0))
+		if err := h.Reload(); err != nil {
+			t.Error(err)
+		}
+	}
+	cancel()
+	wg.Wait()
+
+	if h.Current().Generation != 21 {
+		t.Errorf("expected generation 21, got %d", h.Current().Generation)
+	}
+}
+
+func holder_Poll(t *testing.T) {
+	reloaded := make(chan int, 1)
+	h, path := newTestHolder(t, HolderOptions{OnReload: func(l *Loaded, err error) {
+		if err == nil {
+			select {
+			case reloaded <- l.Generation:
+			default:
+			}
+		}
+	}})
+	<-reloaded
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Poll(ctx, 10*time.Millisecond)
+
+	replaceFile(t, path, holder_v2, time.Unix(2000, 0))
+	select {
+	case g := <-reloaded:
+		if g != 2 {
+			t.Errorf("expected generation 2, got %d", g)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("change not picked up")
+	}
+}
+
+func holder_Signal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no SIGHUP")
+	}
+
+	reloaded := make(chan int, 1)
+	h, path := newTestHolder(t, HolderOptions{OnReload: func(l *Loaded, err error) {
+		if err == nil {
+			select {
+			case reloaded <- l.Generation:
+			default:
+			}
+		}
+	}})
+	<-reloaded
+
+	// keep the default action, exiting, away while ReloadOnSignal starts
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.ReloadOnSignal(ctx, syscall.SIGHUP)
+
+	replaceFile(t, path, holder_v2, time.Unix(2000, 0))
+
+	// the signal may arrive before ReloadOnSignal listens, send until seen
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.After(5 * time.Second)
+	for {
+		if err := p.Signal(syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case g := <-reloaded:
+			if g != 2 {
+				t.Errorf("expected generation 2, got %d", g)
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("signal not handled")
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package cccedictparser
+
+import "strings"
+
+// HomophoneOptions relaxes what counts as the same reading.
+type HomophoneOptions struct {
+	// IgnoreTones matches syllables regardless of their tones.
+	IgnoreTones bool
+	// Confusables treats zh/z, ch/c, sh/s, n/l, an/ang, en/eng, in/ing,
+	// ian/iang and uan/uang as equal.
+	Confusables bool
+}
+
+func confusableKey(syllables []PinyinV1) string {
+	sounds := make([]string, 0, len(syllables))
+	for _, p := range syllables {
+		sounds = append(sounds, confusableSound(p.Sound))
+	}
+	return strings.Join(sounds, " ")
+}
+
+func (idx *Index) buildConfusables() {
+	idx.byConfusable = make(map[string][]int)
+	for i, ci := range idx.entries {
+		if k := confusableKey(keySyllables(ci)); k != "" {
+			idx.byConfusable[k] = append(idx.byConfusable[k], i)
+		}
+	}
+}
+
+func sameSyllable(a PinyinV1, b PinyinV1, opts HomophoneOptions) bool {
+	if !opts.IgnoreTones && a.Tone != b.Tone {
+		return false
+	}
+	if opts.Confusables {
+		return confusableSound(a.Sound) == confusableSound(b.Sound)
+	}
+	return strings.EqualFold(a.Sound, b.Sound)
+}
+
+// Homophones returns the entries, other than ci itself, which are read the
+// same way as ci: the same sounds and tones syllable for syllable.
+// opts relaxes the comparison.
+func (idx *Index) Homophones(ci Ci, opts HomophoneOptions) []Ci {
+	target := keySyllables(ci)
+	if len(target) == 0 {
+		return []Ci{}
+	}
+
+	var ids []int
+	if opts.Confusables {
+		idx.confusableOnce.Do(idx.buildConfusables)
+		ids = idx.byConfusable[confusableKey(target)]
+	} else if opts.IgnoreTones {
+		ids = idx.byToneless[tonelessKey(ci)]
+	} else {
+		ids = idx.byPinyin[tonedKey(ci)]
+	}
+
+	out := make([]Ci, 0, len(ids))
+	for _, i := range ids {
+		e := idx.entries[i]
+		if e.Fantizi == ci.Fantizi && e.Jiantizi == ci.Jiantizi && e.PinyinRaw == ci.PinyinRaw {
+			continue
+		}
+
+		syllables := keySyllables(e)
+		if len(syllables) != len(target) {
+			continue
+		}
+
+		same := true
+		for j := range syllables {
+			if !sameSyllable(syllables[j], target[j], opts) {
+				same = false
+				break
+			}
+		}
+
+		if same {
+			out = append(out, e)
+		}
+	}
+
+	return out
+}
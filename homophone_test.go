@@ -0,0 +1,67 @@
+package cccedictparser
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestHomophones(t *testing.T) {
+	tests := []testItem{
+		{Name: "homophones_Exact", Test: homophones_Exact},
+		{Name: "homophones_IgnoreTones", Test: homophones_IgnoreTones},
+		{Name: "homophones_Confusables", Test: homophones_Confusables},
+		{Name: "homophones_RespectsSyllableBoundaries", Test: homophones_RespectsSyllableBoundaries},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func sortedHeadwords(cis []Ci) string {
+	out := headwords(cis)
+	sort.Strings(out)
+	return strings.Join(out, ",")
+}
+
+func homophones_Exact(t *testing.T) {
+	idx := loadSampleIndex(t)
+	shi4 := idx.LookupPinyin("shi4")[0]
+
+	if got := sortedHeadwords(idx.Homophones(shi4, HomophoneOptions{})); got != "事,试" {
+		t.Errorf("expected 事,试 got %s", got)
+	}
+}
+
+func homophones_IgnoreTones(t *testing.T) {
+	idx := loadSampleIndex(t)
+	shi4 := idx.LookupPinyin("shi4")[0]
+
+	if got := sortedHeadwords(idx.Homophones(shi4, HomophoneOptions{IgnoreTones: true})); got != "事,十,时,试,诗" {
+		t.Errorf("expected 事,十,时,试,诗 got %s", got)
+	}
+}
+
+func homophones_Confusables(t *testing.T) {
+	idx := loadSampleIndex(t)
+	nan2 := idx.Lookup("南")[0]
+
+	if got := sortedHeadwords(idx.Homophones(nan2, HomophoneOptions{Confusables: true})); got != "兰,狼,男,蓝,难" {
+		t.Errorf("expected 兰,狼,男,蓝,难 got %s", got)
+	}
+
+	shi4 := idx.LookupPinyin("shi4")[0]
+	if got := sortedHeadwords(idx.Homophones(shi4, HomophoneOptions{Confusables: true})); got != "事,四,试" {
+		t.Errorf("expected 事,四,试 got %s", got)
+	}
+}
+
+func homophones_RespectsSyllableBoundaries(t *testing.T) {
+	idx := loadSampleIndex(t)
+	xian := idx.Lookup("先")[0]
+
+	if got := idx.Homophones(xian, HomophoneOptions{IgnoreTones: true}); len(got) != 0 {
+		t.Errorf("expected no homophones for xian, got %v", headwords(got))
+	}
+}
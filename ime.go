@@ -0,0 +1,328 @@
+package cccedictparser
+
+import (
+	"sort"
+	"strings"
+)
+
+const ime_max_syllable_len = 6
+const ime_separator = '\''
+
+// Candidate is a word proposed by the IME for the start of the input.
+type Candidate struct {
+	Ci Ci
+	// Consumed is the number of bytes of the raw input covered by the candidate.
+	Consumed int
+	// Partial is true when the last syllable of the candidate was only typed
+	// in part (e.g. "nih" for ni3 hao3).
+	Partial bool
+}
+
+// IME turns a stream of pinyin into hanzi candidates using a dictionary.
+// Only entries whose reading is made of regular pinyin syllables take part.
+type IME struct {
+	idx   *Index
+	pym   map[string]bool
+	root  *imeNode
+	tones [][]Tone
+}
+
+type imeNode struct {
+	children map[string]*imeNode
+	entries  []int
+}
+
+type imeMatch struct {
+	entry   int
+	end     int
+	partial bool
+}
+
+type imeToken struct {
+	syllable string
+	tone     Tone
+	end      int
+}
+
+// NewIME builds an input method over the entries of idx.
+func NewIME(idx *Index) *IME {
+	ime := &IME{
+		idx:   idx,
+		pym:   pinyin_syllables,
+		root:  &imeNode{},
+		tones: make([][]Tone, idx.Len()),
+	}
+
+	for i, ci := range idx.entries {
+		syllables := keySyllables(ci)
+		if len(syllables) == 0 {
+			continue
+		}
+
+		tones := make([]Tone, 0, len(syllables))
+		node := ime.root
+		for _, p := range syllables {
+			if p.Type != Normal {
+				node = nil
+				break
+			}
+			key := strings.ToLower(p.Sound)
+			if node.children == nil {
+				node.children = make(map[string]*imeNode)
+			}
+			child, ok := node.children[key]
+			if !ok {
+				child = &imeNode{}
+				node.children[key] = child
+			}
+			node = child
+			tones = append(tones, p.Tone)
+		}
+
+		if node == nil {
+			continue
+		}
+		node.entries = append(node.entries, i)
+		ime.tones[i] = tones
+	}
+
+	return ime
+}
+
+// normalizeIMEInput lower-cases the input, maps "ü"/"u:" to "v" and spaces to
+// the syllable separator. It returns the normalized input together with the
+// offset in the raw input of every normalized byte (plus the end offset).
+func normalizeIMEInput(raw string) (string, []int) {
+	var b strings.Builder
+	offsets := make([]int, 0, len(raw)+1)
+
+	for i := 0; i < len(raw); {
+		c := raw[i]
+		switch {
+		case c >= 'A' && c <= 'Z':
+			b.WriteByte(c + ('a' - 'A'))
+			offsets = append(offsets, i)
+			i++
+		case c == 'u' && i+1 < len(raw) && raw[i+1] == ':':
+			b.WriteByte('v')
+			offsets = append(offsets, i)
+			i += 2
+		case strings.HasPrefix(raw[i:], "ü") || strings.HasPrefix(raw[i:], "Ü"):
+			b.WriteByte('v')
+			offsets = append(offsets, i)
+			i += len("ü")
+		case c == ' ' || c == ime_separator:
+			b.WriteByte(ime_separator)
+			offsets = append(offsets, i)
+			i++
+		default:
+			b.WriteByte(c)
+			offsets = append(offsets, i)
+			i++
+		}
+	}
+
+	offsets = append(offsets, len(raw))
+	return b.String(), offsets
+}
+
+func skipSeparators(in string, p int) int {
+	for p < len(in) && in[p] == ime_separator {
+		p++
+	}
+	return p
+}
+
+// tokensAt lists every complete syllable (with optional tone number) which
+// can be read at position p.
+func (ime *IME) tokensAt(in string, p int) []imeToken {
+	p = skipSeparators(in, p)
+	var tokens []imeToken
+
+	for l := 1; l <= ime_max_syllable_len && p+l <= len(in); l++ {
+		s := in[p : p+l]
+		if !ime.pym[s] {
+			continue
+		}
+		end := p + l
+		tone := None
+		if end < len(in) {
+			if t, err := getTone(rune(in[end])); err == nil {
+				tone = t
+				end++
+			}
+		}
+		tokens = append(tokens, imeToken{syllable: s, tone: tone, end: end})
+	}
+
+	return tokens
+}
+
+// partialAt returns the rest of the input if it is the unfinished beginning
+// of a syllable.
+func (ime *IME) partialAt(in string, p int) (string, bool) {
+	p = skipSeparators(in, p)
+	rest := in[p:]
+	if rest == "" || len(rest) >= ime_max_syllable_len || strings.ContainsAny(rest, "'12345") {
+		return "", false
+	}
+	return rest, true
+}
+
+func (ime *IME) tonesMatch(entry int, tones []Tone) bool {
+	et := ime.tones[entry]
+	for i, t := range tones {
+		if t != None && et[i] != t {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAt reports every dictionary word which can be read starting at p.
+func (ime *IME) matchesAt(in string, p int, fn func(imeMatch)) {
+	ime.walk(ime.root, in, p, make([]Tone, 0, 8), fn)
+}
+
+func (ime *IME) walk(node *imeNode, in string, p int, tones []Tone, fn func(imeMatch)) {
+	for _, e := range node.entries {
+		if ime.tonesMatch(e, tones) {
+			fn(imeMatch{entry: e, end: p})
+		}
+	}
+
+	if len(node.children) == 0 || skipSeparators(in, p) == len(in) {
+		return
+	}
+
+	for _, tok := range ime.tokensAt(in, p) {
+		if child, ok := node.children[tok.syllable]; ok {
+			ime.walk(child, in, tok.end, append(tones, tok.tone), fn)
+		}
+	}
+
+	if partial, ok := ime.partialAt(in, p); ok {
+		for key, child := range node.children {
+			if key == partial || !strings.HasPrefix(key, partial) {
+				continue
+			}
+			for _, e := range child.entries {
+				if ime.tonesMatch(e, append(tones, None)) {
+					fn(imeMatch{entry: e, end: len(in), partial: true})
+				}
+			}
+		}
+	}
+}
+
+// imeWeight is a rough frequency proxy: entries with many senses tend to be
+// common words.
+func (ime *IME) imeWeight(entry int) int {
+	return min(len(ime.idx.entries[entry].Gloss), 5)
+}
+
+// Candidates returns up to limit words which can start the input, best first.
+// Words covering more of the input rank higher, so for "zhongguoren" the
+// first candidate is 中国人, followed by 中国 and 中. A limit <= 0 returns
+// every candidate.
+func (ime *IME) Candidates(input string, limit int) []Candidate {
+	in, offsets := normalizeIMEInput(input)
+
+	best := make(map[int]imeMatch)
+	ime.matchesAt(in, 0, func(m imeMatch) {
+		if cur, ok := best[m.entry]; !ok || m.end > cur.end || (m.end == cur.end && cur.partial && !m.partial) {
+			best[m.entry] = m
+		}
+	})
+
+	matches := make([]imeMatch, 0, len(best))
+	for _, m := range best {
+		matches = append(matches, m)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.end != b.end {
+			return a.end > b.end
+		}
+		if a.partial != b.partial {
+			return !a.partial
+		}
+		if wa, wb := ime.imeWeight(a.entry), ime.imeWeight(b.entry); wa != wb {
+			return wa > wb
+		}
+		return a.entry < b.entry
+	})
+
+	seen := make(map[string]bool)
+	out := make([]Candidate, 0, len(matches))
+	for _, m := range matches {
+		ci := ime.idx.entries[m.entry]
+		if seen[ci.Jiantizi] {
+			continue
+		}
+		seen[ci.Jiantizi] = true
+
+		out = append(out, Candidate{
+			Ci:       ci,
+			Consumed: offsets[m.end],
+			Partial:  m.partial,
+		})
+
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+
+	return out
+}
+
+// Compose converts the whole input into a sentence of dictionary words.
+// Segmentations with fewer, more common words are preferred. It returns nil
+// when the input cannot be covered by dictionary words.
+func (ime *IME) Compose(input string) []Ci {
+	in, _ := normalizeIMEInput(strings.TrimRight(input, " '"))
+	if in == "" {
+		return nil
+	}
+
+	// cost[p] is the cheapest way to write in[:p], -1 when unreachable
+	cost := make([]int, len(in)+1)
+	from := make([]int, len(in)+1)
+	word := make([]int, len(in)+1)
+	for i := range cost {
+		cost[i] = -1
+	}
+	cost[0] = 0
+
+	for p := 0; p < len(in); p++ {
+		if cost[p] < 0 {
+			continue
+		}
+		ime.matchesAt(in, p, func(m imeMatch) {
+			if m.end == p {
+				return
+			}
+			c := cost[p] + 10 - ime.imeWeight(m.entry)
+			if cost[m.end] < 0 || c < cost[m.end] {
+				cost[m.end] = c
+				from[m.end] = p
+				word[m.end] = m.entry
+			}
+		})
+	}
+
+	if cost[len(in)] < 0 {
+		return nil
+	}
+
+	var sentence []Ci
+	for p := len(in); p > 0; p = from[p] {
+		sentence = append(sentence, ime.idx.entries[word[p]])
+	}
+	for i, j := 0, len(sentence)-1; i < j; i, j = i+1, j-1 {
+		sentence[i], sentence[j] = sentence[j], sentence[i]
+	}
+
+	return sentence
+}
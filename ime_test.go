@@ -0,0 +1,103 @@
+package cccedictparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIME(t *testing.T) {
+	tests := []testItem{
+		{Name: "ime_CandidatesLongestFirst", Test: ime_CandidatesLongestFirst},
+		{Name: "ime_CandidatesWithTones", Test: ime_CandidatesWithTones},
+		{Name: "ime_CandidatesPartialSyllable", Test: ime_CandidatesPartialSyllable},
+		{Name: "ime_CandidatesConsumedRawBytes", Test: ime_CandidatesConsumedRawBytes},
+		{Name: "ime_Compose", Test: ime_Compose},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func candidateHeadwords(cs []Candidate) []string {
+	out := make([]string, 0, len(cs))
+	for _, v := range cs {
+		out = append(out, v.Ci.Jiantizi)
+	}
+	return out
+}
+
+func ime_CandidatesLongestFirst(t *testing.T) {
+	ime := NewIME(loadSampleIndex(t))
+
+	got := candidateHeadwords(ime.Candidates("zhongguoren", 3))
+	expected := []string{"中国人", "中国", "中"}
+
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func ime_CandidatesWithTones(t *testing.T) {
+	ime := NewIME(loadSampleIndex(t))
+
+	for _, v := range ime.Candidates("hao4", 0) {
+		for _, p := range v.Ci.Pinyin {
+			if p.Word[0].Tone != T4 {
+				t.Errorf("candidate %s does not have tone 4", v.Ci.String())
+			}
+		}
+	}
+
+	got := candidateHeadwords(ime.Candidates("shi2", 0))
+	if strings.Join(got, ",") != "时,十" {
+		t.Errorf("expected [时 十], got %v", got)
+	}
+}
+
+func ime_CandidatesPartialSyllable(t *testing.T) {
+	ime := NewIME(loadSampleIndex(t))
+
+	cands := ime.Candidates("nih", 0)
+	if len(cands) == 0 {
+		t.Fatal("expected candidates for partial input")
+	}
+
+	if cands[0].Ci.Jiantizi != "你好" || !cands[0].Partial {
+		t.Errorf("expected partial 你好 first, got %v", candidateHeadwords(cands))
+	}
+}
+
+func ime_CandidatesConsumedRawBytes(t *testing.T) {
+	ime := NewIME(loadSampleIndex(t))
+
+	input := "Nü3 ren2"
+	cands := ime.Candidates(input, 1)
+	if len(cands) != 1 || cands[0].Ci.Jiantizi != "女" {
+		t.Fatalf("expected 女, got %v", candidateHeadwords(cands))
+	}
+
+	if input[:cands[0].Consumed] != "Nü3" {
+		t.Errorf("expected candidate to consume \"Nü3\", consumed %q", input[:cands[0].Consumed])
+	}
+}
+
+func ime_Compose(t *testing.T) {
+	ime := NewIME(loadSampleIndex(t))
+
+	cases := []testCase[string]{
+		{Sentence: "woshizhongguoren", Expected: "我是中国人"},
+		{Sentence: "women xuexi", Expected: "我们学习"},
+		{Sentence: "xi'an", Expected: "西安"},
+		{Sentence: "xian", Expected: "先"},
+		{Sentence: "dax", Expected: "大学"},
+		{Sentence: "qqq", Expected: ""},
+	}
+
+	for _, v := range cases {
+		got := strings.Join(headwords(ime.Compose(v.Sentence)), "")
+		if got != v.Expected {
+			t.Errorf("compose %s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package cccedictparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// LineError describes a line of a dictionary file that could not be parsed.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err.Error())
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// isSkippableLine reports whether a line carries no entry (comments and blank lines).
+func isSkippableLine(line string) bool {
+	return strings.HasPrefix(line, "#") || strings.TrimSpace(line) == ""
+}
+
+// ReadDictionary parses every entry of a cc-cedict file.
+// Comment and blank lines are skipped. Lines which fail to parse do not stop
+// the read; they are returned joined in the error as *LineError values, so a
+// lenient caller can keep the entries and a strict caller can reject the file.
+func ReadDictionary(r io.Reader) ([]Ci, error) {
+	var entries []Ci
+	var errs []error
+
+	for e, err := range Entries(r) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		entries = append(entries, e.Ci)
+	}
+
+	return entries, errors.Join(errs...)
+}
+
+// Index is an in-memory lookup structure over a loaded dictionary.
+// Entries are referred to by their position in the slice given to NewIndex.
+type Index struct {
+	entries       []Ci
+	bySimplified  map[string][]int
+	byTraditional map[string][]int
+	// toned keys, e.g. "zhong1guo2"
+	byPinyin map[string][]int
+	// toneless keys, e.g. "zhongguo"
+	byToneless map[string][]int
+
+	// built on first use by Homophones
+	confusableOnce sync.Once
+	byConfusable   map[string][]int
+
+	// built on first use by ByTonePattern and ByRhyme
+	toneOnce     sync.Once
+	byContourLen map[int][]toneEntry
+	byFinal      map[string][]int
+
+	// built on first use by the headword pattern queries
+	headwordOnce sync.Once
+	headwords    *headwordIndex
+
+	// built on first use by the English searches
+	englishOnce sync.Once
+	byEnglish   map[string][]int
+
+	// built on first use by Segment, in runes
+	segmentOnce sync.Once
+	maxWordLen  int
+
+	// built on first use by Trie
+	trieOnce sync.Once
+	trie     *Trie
+}
+
+// NewIndex builds an index over entries. The slice is retained, not copied.
+func NewIndex(entries []Ci) *Index {
+	idx := &Index{
+		entries:       entries,
+		bySimplified:  make(map[string][]int, len(entries)),
+		byTraditional: make(map[string][]int, len(entries)),
+		byPinyin:      make(map[string][]int, len(entries)),
+		byToneless:    make(map[string][]int, len(entries)),
+	}
+
+	for i, ci := range entries {
+		idx.bySimplified[ci.Jiantizi] = append(idx.bySimplified[ci.Jiantizi], i)
+		idx.byTraditional[ci.Fantizi] = append(idx.byTraditional[ci.Fantizi], i)
+
+		if k := tonedKey(ci); k != "" {
+			idx.byPinyin[k] = append(idx.byPinyin[k], i)
+		}
+		if k := tonelessKey(ci); k != "" {
+			idx.byToneless[k] = append(idx.byToneless[k], i)
+		}
+	}
+
+	return idx
+}
+
+// Len returns the number of indexed entries.
+func (idx *Index) Len() int {
+	return len(idx.entries)
+}
+
+// Entry returns the i-th indexed entry.
+func (idx *Index) Entry(i int) Ci {
+	return idx.entries[i]
+}
+
+// Entries returns the indexed entries. The slice must not be modified.
+func (idx *Index) Entries() []Ci {
+	return idx.entries
+}
+
+func (idx *Index) collect(ids ...[]int) []Ci {
+	seen := make(map[int]bool)
+	out := make([]Ci, 0)
+	for _, list := range ids {
+		for _, i := range list {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			out = append(out, idx.entries[i])
+		}
+	}
+	return out
+}
+
+// Lookup returns the entries whose simplified or traditional headword is word.
+func (idx *Index) Lookup(word string) []Ci {
+	return idx.collect(idx.bySimplified[word], idx.byTraditional[word])
+}
+
+// LookupPinyin returns the entries read as pinyin. Syllables may be separated
+// by spaces or written together, "u:" and "ü" are accepted for "v" and case is
+// ignored. Queries with tone numbers ("zhong1 guo2") match tones exactly,
+// queries without ("zhongguo") match any tone.
+func (idx *Index) LookupPinyin(pinyin string) []Ci {
+	q := normalizePinyinQuery(pinyin)
+	if strings.ContainsAny(q, "12345") {
+		return idx.collect(idx.byPinyin[q])
+	}
+	return idx.collect(idx.byToneless[q])
+}
+
+func normalizePinyinQuery(s string) string {
+	s = strings.ToLower(s)
+	s = strings.NewReplacer("u:", "v", "ü", "v", " ", "", "'", "").Replace(s)
+	return s
+}
+
+// keySyllables returns the syllables of ci which take part in pinyin keys,
+// in reading order. Punctuation and other special syllables are dropped.
+func keySyllables(ci Ci) []PinyinV1 {
+	out := make([]PinyinV1, 0, len(ci.Pinyin))
+	for p := range ci.Syllables() {
+		if p.Type != Special {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func tonedKey(ci Ci) string {
+	var b strings.Builder
+	for _, p := range keySyllables(ci) {
+		b.WriteString(strings.ToLower(p.Sound))
+		if p.Tone != None {
+			b.WriteByte('0' + p.Tone)
+		}
+	}
+	return b.String()
+}
+
+func tonelessKey(ci Ci) string {
+	var b strings.Builder
+	for _, p := range keySyllables(ci) {
+		b.WriteString(strings.ToLower(p.Sound))
+	}
+	return b.String()
+}
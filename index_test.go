@@ -0,0 +1,106 @@
+package cccedictparser
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+const sampleDictionary = "testdata/sample.u8"
+
+func loadSample(t testing.TB) []Ci {
+	t.Helper()
+	f, err := os.Open(sampleDictionary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	entries, err := ReadDictionary(f)
+	if err != nil {
+		t.Fatalf("sample dictionary did not parse cleanly: %s", err.Error())
+	}
+	return entries
+}
+
+func loadSampleIndex(t testing.TB) *Index {
+	t.Helper()
+	return NewIndex(loadSample(t))
+}
+
+func headwords(cis []Ci) []string {
+	out := make([]string, 0, len(cis))
+	for _, v := range cis {
+		out = append(out, v.Jiantizi)
+	}
+	return out
+}
+
+func TestIndex(t *testing.T) {
+	tests := []testItem{
+		{Name: "readDictionary_ReportsLineErrors", Test: readDictionary_ReportsLineErrors},
+		{Name: "index_LookupBothScripts", Test: index_LookupBothScripts},
+		{Name: "index_LookupPinyin", Test: index_LookupPinyin},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func readDictionary_ReportsLineErrors(t *testing.T) {
+	in := "# comment\n\n海嘯 海啸 [hai3 xiao4] /tsunami/\n浮泛 浮泛 [fu2 fan4] \n禁酒 禁酒 [jin4 jiu3] /prohibition/\n"
+
+	entries, err := ReadDictionary(strings.NewReader(in))
+
+	if len(entries) != 2 {
+		t.Errorf("expected 2 entries, got %d", len(entries))
+	}
+
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) {
+		t.Fatalf("expected a line error, got %v", err)
+	}
+
+	if lineErr.Line != 4 {
+		t.Errorf("expected error on line 4, got line %d", lineErr.Line)
+	}
+}
+
+func index_LookupBothScripts(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	cases := []testCase[int]{
+		{Sentence: "中國", Expected: 1},
+		{Sentence: "中国", Expected: 1},
+		{Sentence: "中", Expected: 3},
+		{Sentence: "不存在", Expected: 0},
+	}
+
+	for _, v := range cases {
+		if got := idx.Lookup(v.Sentence); len(got) != v.Expected {
+			t.Errorf("lookup %s: expected %d entries, got %d", v.Sentence, v.Expected, len(got))
+		}
+	}
+}
+
+func index_LookupPinyin(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	cases := []testCase[[]string]{
+		{Sentence: "zhong1 guo2", Expected: []string{"中国"}},
+		{Sentence: "ZhongGuo", Expected: []string{"中国"}},
+		{Sentence: "hao3", Expected: []string{"好"}},
+		{Sentence: "hao", Expected: []string{"好", "好", "号"}},
+		{Sentence: "nü3", Expected: []string{"女"}},
+		{Sentence: "lu:4", Expected: []string{"绿"}},
+	}
+
+	for _, v := range cases {
+		got := headwords(idx.LookupPinyin(v.Sentence))
+		if strings.Join(got, ",") != strings.Join(v.Expected, ",") {
+			t.Errorf("lookup pinyin %s: expected %v, got %v", v.Sentence, v.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package cccedictparser
+
+import (
+	"bufio"
+	"io"
+	"iter"
+	"strings"
+)
+
+// Entry is an entry yielded by Entries with the line it was read from,
+// counting from 1.
+type Entry struct {
+	Line int
+	Ci   Ci
+}
+
+// Entries returns an iterator over the entries of a dictionary read from r,
+// one line at a time, with their line numbers. A line which fails to parse
+// is yielded as a *LineError with its line number and the iteration goes on;
+// an error reading r is yielded last. Comments and blank lines are skipped.
+//
+//	for e, err := range cccedictparser.Entries(f) {
+//		var lineErr *cccedictparser.LineError
+//		if errors.As(err, &lineErr) {
+//			log.Printf("skipping line %d: %v", e.Line, lineErr.Err)
+//			continue
+//		} else if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// The iterator reads r as it goes, so it can be ranged over only once.
+func Entries(r io.Reader) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		lineParser := NewLineParser()
+		lineNo := 0
+
+		for scanner.Scan() {
+			lineNo++
+			l := strings.TrimSuffix(scanner.Text(), "\r")
+
+			if isSkippableLine(l) {
+				continue
+			}
+
+			ci, err := lineParser.ParseLine(l)
+			if err != nil {
+				if !yield(Entry{Line: lineNo}, &LineError{Line: lineNo, Err: err}) {
+					return
+				}
+				continue
+			}
+			if !yield(Entry{Line: lineNo, Ci: ci}, nil) {
+				return
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			yield(Entry{Line: lineNo}, err)
+		}
+	}
+}
+
+// Syllables returns an iterator over the syllables of ci across its words,
+// in reading order, punctuation and other special syllables included.
+func (ci Ci) Syllables() iter.Seq[PinyinV1] {
+	return func(yield func(PinyinV1) bool) {
+		for _, w := range ci.Pinyin {
+			for _, p := range w.Word {
+				if !yield(p) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cccedictparser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestIter(t *testing.T) {
+	tests := []testItem{
+		{Name: "entries_MatchesReadDictionary", Test: entries_MatchesReadDictionary},
+		{Name: "entries_LineErrors", Test: entries_LineErrors},
+		{Name: "entries_Break", Test: entries_Break},
+		{Name: "ci_Syllables", Test: ci_Syllables},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func entries_MatchesReadDictionary(t *testing.T) {
+	f, err := os.Open(sampleDictionary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var got []Ci
+	var lines []int
+	for e, err := range Entries(f) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, e.Ci)
+		lines = append(lines, e.Line)
+	}
+
+	if expected := loadSample(t); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %d entries, got %d", len(expected), len(got))
+	}
+	if len(lines) == 0 || lines[0] <= 1 || !slices.IsSorted(lines) {
+		t.Errorf("expected increasing line numbers past the header comments, got %v", lines)
+	}
+}
+
+func entries_LineErrors(t *testing.T) {
+	in := "# comment\n\n海嘯 海啸 [hai3 xiao4] /tsunami/\n浮泛 浮泛 [fu2 fan4] \n禁酒 禁酒 [jin4 jiu3] /prohibition/\n"
+
+	var got []string
+	for e, err := range Entries(strings.NewReader(in)) {
+		var lineErr *LineError
+		if errors.As(err, &lineErr) {
+			got = append(got, fmt.Sprintf("error %d/%d", e.Line, lineErr.Line))
+			continue
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%s %d", e.Ci.Jiantizi, e.Line))
+	}
+
+	expected := []string{"海啸 3", "error 4/4", "禁酒 5"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func entries_Break(t *testing.T) {
+	f, err := os.Open(sampleDictionary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	n := 0
+	for range Entries(f) {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("expected to stop after 2 entries, got %d", n)
+	}
+}
+
+func ci_Syllables(t *testing.T) {
+	lp := NewLineParser()
+
+	cases := []testCase[string]{
+		{Sentence: "中國 中国 [[Zhong1guo2]] /China/", Expected: "Zhong1 guo2"},
+		{Sentence: "一模一樣 一模一样 [[yi1mu2-yi1yang4]] /exactly the same/", Expected: "yi1 mu2 -0 yi1 yang4"},
+		{Sentence: "大衛·愛登堡 大卫·爱登堡 [Da4 wei4 · Ai4 deng1 bao3] /David Attenborough/", Expected: "Da4 wei4 ·0 Ai4 deng1 bao3"},
+	}
+
+	for _, v := range cases {
+		ci, err := lp.ParseLine(v.Sentence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for p := range ci.Syllables() {
+			got = append(got, fmt.Sprintf("%s%d", p.Sound, p.Tone))
+		}
+		if strings.Join(got, " ") != v.Expected {
+			t.Errorf("syllables of %s: expected %s, got %v", v.Sentence, v.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package cccedictparser
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JSON schema
+//
+// A Ci is encoded as an object:
+//
+//	{
+//	  "headword": {"traditional": "中國", "simplified": "中国"},
+//	  "pinyin": [[{"sound": "Zhong", "tone": "first", "type": "normal"}], [{"sound": "guo", "tone": "second", "type": "normal"}]],
+//	  "pinyin_raw": "Zhong1 guo2",
+//	  "gloss": ["China"],
+//	  "format": "V1"
+//	}
+//
+// "pinyin" holds one array per PinyinV2 word, each holding that word's
+// syllables. A syllable's "tone" is one of "none", "first", "second", "third",
+// "fourth" or "neutral" and its "type" one of "unknown", "normal", "alphabet"
+// or "special". "gloss" lists the senses in order and "format" is "V1" or
+// "V2". Every field is always present. NDJSON streams hold one such object per
+// line.
+
+var tone_names = []string{
+	None: "none",
+	T1:   "first",
+	T2:   "second",
+	T3:   "third",
+	T4:   "fourth",
+	T5:   "neutral",
+}
+
+var pinyin_type_names = []string{
+	Unknown:  "unknown",
+	Normal:   "normal",
+	Alphabet: "alphabet",
+	Special:  "special",
+}
+
+// ToneName returns the JSON name of a tone, e.g. "third" for T3.
+func ToneName(t Tone) string {
+	if int(t) < len(tone_names) {
+		return tone_names[t]
+	}
+	return fmt.Sprintf("tone(%d)", t)
+}
+
+// ParseToneName is the inverse of ToneName.
+func ParseToneName(name string) (Tone, error) {
+	for i, v := range tone_names {
+		if v == name {
+			return Tone(i), nil
+		}
+	}
+	return None, fmt.Errorf("unrecognized tone name (%s)", name)
+}
+
+// PinyinTypeName returns the JSON name of a pinyin type, e.g. "alphabet".
+func PinyinTypeName(t PinyinType) string {
+	if int(t) < len(pinyin_type_names) {
+		return pinyin_type_names[t]
+	}
+	return fmt.Sprintf("type(%d)", t)
+}
+
+// ParsePinyinTypeName is the inverse of PinyinTypeName.
+func ParsePinyinTypeName(name string) (PinyinType, error) {
+	for i, v := range pinyin_type_names {
+		if v == name {
+			return PinyinType(i), nil
+		}
+	}
+	return Unknown, fmt.Errorf("unrecognized pinyin type name (%s)", name)
+}
+
+type jsonPinyinV1 struct {
+	Sound string `json:"sound"`
+	Tone  string `json:"tone"`
+	Type  string `json:"type"`
+}
+
+type jsonHeadword struct {
+	Traditional string `json:"traditional"`
+	Simplified  string `json:"simplified"`
+}
+
+type jsonCi struct {
+	Headword  jsonHeadword `json:"headword"`
+	Pinyin    []PinyinV2   `json:"pinyin"`
+	PinyinRaw string       `json:"pinyin_raw"`
+	Gloss     []string     `json:"gloss"`
+	Format    string       `json:"format"`
+}
+
+func (p PinyinV1) MarshalJSON() ([]byte, error) {
+	if int(p.Tone) >= len(tone_names) {
+		return nil, fmt.Errorf("cannot encode tone %d", p.Tone)
+	}
+	if int(p.Type) >= len(pinyin_type_names) {
+		return nil, fmt.Errorf("cannot encode pinyin type %d", p.Type)
+	}
+	return json.Marshal(jsonPinyinV1{
+		Sound: p.Sound,
+		Tone:  ToneName(p.Tone),
+		Type:  PinyinTypeName(p.Type),
+	})
+}
+
+func (p *PinyinV1) UnmarshalJSON(data []byte) error {
+	var j jsonPinyinV1
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	tone, err := ParseToneName(j.Tone)
+	if err != nil {
+		return err
+	}
+	t, err := ParsePinyinTypeName(j.Type)
+	if err != nil {
+		return err
+	}
+
+	*p = PinyinV1{Sound: j.Sound, Tone: tone, Type: t}
+	return nil
+}
+
+func (p PinyinV2) MarshalJSON() ([]byte, error) {
+	word := p.Word
+	if word == nil {
+		word = []PinyinV1{}
+	}
+	return json.Marshal(word)
+}
+
+func (p *PinyinV2) UnmarshalJSON(data []byte) error {
+	var word []PinyinV1
+	if err := json.Unmarshal(data, &word); err != nil {
+		return err
+	}
+	*p = PinyinV2{Word: word}
+	return nil
+}
+
+func (ci Ci) MarshalJSON() ([]byte, error) {
+	j := jsonCi{
+		Headword:  jsonHeadword{Traditional: ci.Fantizi, Simplified: ci.Jiantizi},
+		Pinyin:    ci.Pinyin,
+		PinyinRaw: ci.PinyinRaw,
+		Gloss:     ci.Gloss,
+		Format:    ci.FormatVersion,
+	}
+	if j.Pinyin == nil {
+		j.Pinyin = []PinyinV2{}
+	}
+	if j.Gloss == nil {
+		j.Gloss = []string{}
+	}
+	return json.Marshal(j)
+}
+
+func (ci *Ci) UnmarshalJSON(data []byte) error {
+	var j jsonCi
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	if j.Format != V1 && j.Format != V2 {
+		return fmt.Errorf("unrecognized format version (%s)", j.Format)
+	}
+
+	*ci = Ci{
+		Fantizi:       j.Headword.Traditional,
+		Jiantizi:      j.Headword.Simplified,
+		Pinyin:        j.Pinyin,
+		PinyinRaw:     j.PinyinRaw,
+		Gloss:         j.Gloss,
+		FormatVersion: j.Format,
+	}
+	return nil
+}
+
+// NDJSONEncoder writes entries as newline delimited JSON, one Ci per line.
+type NDJSONEncoder struct {
+	enc *json.Encoder
+}
+
+func NewNDJSONEncoder(w io.Writer) *NDJSONEncoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &NDJSONEncoder{enc: enc}
+}
+
+func (e *NDJSONEncoder) Encode(ci Ci) error {
+	return e.enc.Encode(ci)
+}
+
+// NDJSONDecoder reads entries written by NDJSONEncoder. Blank lines are
+// skipped.
+type NDJSONDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func NewNDJSONDecoder(r io.Reader) *NDJSONDecoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return &NDJSONDecoder{scanner: scanner}
+}
+
+// Decode returns the next entry, or io.EOF once the stream is exhausted.
+// Malformed lines are reported as *LineError.
+func (d *NDJSONDecoder) Decode() (Ci, error) {
+	for d.scanner.Scan() {
+		d.line++
+		l := d.scanner.Bytes()
+		if strings.TrimSpace(string(l)) == "" {
+			continue
+		}
+
+		var ci Ci
+		if err := json.Unmarshal(l, &ci); err != nil {
+			return Ci{}, &LineError{Line: d.line, Err: err}
+		}
+		return ci, nil
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		return Ci{}, err
+	}
+	return Ci{}, io.EOF
+}
+
+// WriteNDJSON writes a whole dictionary as NDJSON.
+func WriteNDJSON(w io.Writer, entries []Ci) error {
+	bw := bufio.NewWriter(w)
+	enc := NewNDJSONEncoder(bw)
+	for _, ci := range entries {
+		if err := enc.Encode(ci); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadNDJSON reads a whole NDJSON dictionary, stopping at the first error.
+func ReadNDJSON(r io.Reader) ([]Ci, error) {
+	dec := NewNDJSONDecoder(r)
+	var entries []Ci
+	for {
+		ci, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, ci)
+	}
+}
@@ -0,0 +1,133 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	tests := []testItem{
+		{Name: "json_MarshalSchema", Test: json_MarshalSchema},
+		{Name: "json_RoundTrip", Test: json_RoundTrip},
+		{Name: "json_UnmarshalErrors", Test: json_UnmarshalErrors},
+		{Name: "ndjson_RoundTrip", Test: ndjson_RoundTrip},
+		{Name: "ndjson_LineError", Test: ndjson_LineError},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func json_MarshalSchema(t *testing.T) {
+	ci, err := ParseLine("K人 K人 [K ren2] /(slang) to hit sb; to beat sb/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := json.Marshal(ci)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"headword":{"traditional":"K人","simplified":"K人"},"pinyin":[[{"sound":"K","tone":"none","type":"alphabet"}],[{"sound":"ren","tone":"second","type":"normal"}]],"pinyin_raw":"K ren2","gloss":["(slang) to hit sb; to beat sb"],"format":"V1"}`
+	if string(out) != expected {
+		t.Errorf("expected %s, got %s", expected, string(out))
+	}
+
+	out, err = json.Marshal(Ci{FormatVersion: V1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `"pinyin":[]`) || !strings.Contains(string(out), `"gloss":[]`) {
+		t.Errorf("expected empty arrays rather than null, got %s", string(out))
+	}
+}
+
+func json_RoundTrip(t *testing.T) {
+	for _, ci := range loadSample(t) {
+		out, err := json.Marshal(ci)
+		if err != nil {
+			t.Errorf("marshal %s: %s", ci.String(), err.Error())
+			continue
+		}
+
+		var back Ci
+		if err := json.Unmarshal(out, &back); err != nil {
+			t.Errorf("unmarshal %s: %s", string(out), err.Error())
+			continue
+		}
+
+		if !ciEq(ci, back) {
+			t.Errorf("round trip mismatch: expected %s, got %s", ci.String(), back.String())
+		}
+	}
+}
+
+func json_UnmarshalErrors(t *testing.T) {
+	cases := []testCase[string]{
+		{Sentence: `{"sound":"ren","tone":"2","type":"normal"}`, Expected: "unrecognized tone name"},
+		{Sentence: `{"sound":"ren","tone":"second","type":"word"}`, Expected: "unrecognized pinyin type name"},
+	}
+
+	for _, v := range cases {
+		var p PinyinV1
+		err := json.Unmarshal([]byte(v.Sentence), &p)
+		if err == nil || !strings.Contains(err.Error(), v.Expected) {
+			t.Errorf("expected error containing %s for %s, got %v", v.Expected, v.Sentence, err)
+		}
+	}
+
+	var ci Ci
+	if err := json.Unmarshal([]byte(`{"headword":{},"pinyin":[],"gloss":[],"format":"V3"}`), &ci); err == nil {
+		t.Error("expected error for unknown format version")
+	}
+}
+
+func ndjson_RoundTrip(t *testing.T) {
+	entries := loadSample(t)
+
+	var buf bytes.Buffer
+	if err := WriteNDJSON(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Count(buf.String(), "\n"); lines != len(entries) {
+		t.Errorf("expected %d lines, got %d", len(entries), lines)
+	}
+
+	back, err := ReadNDJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(back) != len(entries) {
+		t.Fatalf("expected %d entries, got %d", len(entries), len(back))
+	}
+	for i := range entries {
+		if !ciEq(entries[i], back[i]) {
+			t.Errorf("entry %d mismatch: expected %s, got %s", i, entries[i].String(), back[i].String())
+		}
+	}
+}
+
+func ndjson_LineError(t *testing.T) {
+	in := `{"headword":{"traditional":"人","simplified":"人"},"pinyin":[[{"sound":"ren","tone":"second","type":"normal"}]],"pinyin_raw":"ren2","gloss":["person"],"format":"V1"}
+
+not json
+`
+	dec := NewNDJSONDecoder(strings.NewReader(in))
+
+	if _, err := dec.Decode(); err != nil {
+		t.Fatalf("unexpected error on first line: %s", err.Error())
+	}
+
+	_, err := dec.Decode()
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != 3 {
+		t.Errorf("expected a line error on line 3, got %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package cccedictparser
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+)
+
+// load_batch_lines is the number of lines handed to a worker at once, large
+// enough that channel traffic does not dominate the parsing.
+const load_batch_lines = 1024
+
+// LoadProgress reports how far LoadDictionary got, counting lines in order.
+type LoadProgress struct {
+	// Lines is the number of lines read, including comments.
+	Lines int
+	// Entries is the number of entries parsed.
+	Entries int
+	// Bytes is the size of the lines read, to compare with the file size.
+	Bytes int64
+}
+
+// LoadOptions configures LoadDictionary.
+type LoadOptions struct {
+	// Workers is the number of parsing goroutines, runtime.GOMAXPROCS(0)
+	// when zero or negative.
+	Workers int
+	// Progress, if set, is called after each batch of lines, from the
+	// goroutine calling LoadDictionary.
+	Progress func(p LoadProgress)
+}
+
+type loadBatch struct {
+	seq       int
+	firstLine int
+	lines     []string
+	bytes     int64
+}
+
+type loadResult struct {
+	seq     int
+	lines   int
+	bytes   int64
+	entries []Ci
+	errs    []error
+}
+
+// LoadDictionary parses a cc-cedict file like ReadDictionary, with the lines
+// parsed by several goroutines. Entries are returned in file order and lines
+// which fail to parse are returned joined in the error as *LineError values,
+// as ReadDictionary does.
+//
+// When ctx is done LoadDictionary returns ctx.Err() without entries. A read
+// blocked on r is not interrupted, the reading goroutine ends after it.
+func LoadDictionary(ctx context.Context, r io.Reader, opts LoadOptions) ([]Ci, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batches := make(chan loadBatch, workers)
+	results := make(chan loadResult, workers)
+	readErr := make(chan error, 1)
+
+	go func() {
+		defer close(batches)
+		readErr <- scanBatches(ctx, r, batches)
+	}()
+
+	lineParser := NewLineParser()
+	done := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for b := range batches {
+				select {
+				case results <- parseBatch(lineParser, b):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		for i := 0; i < workers; i++ {
+			<-done
+		}
+		close(results)
+	}()
+
+	// results arrive in any order, pending holds them until their turn
+	var entries []Ci
+	var errs []error
+	var progress LoadProgress
+	pending := make(map[int]loadResult)
+	next := 0
+
+	for {
+		var res loadResult
+		var ok bool
+		select {
+		case res, ok = <-results:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if !ok {
+			break
+		}
+
+		pending[res.seq] = res
+		for {
+			res, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			entries = append(entries, res.entries...)
+			errs = append(errs, res.errs...)
+			progress.Lines += res.lines
+			progress.Entries += len(res.entries)
+			progress.Bytes += res.bytes
+			if opts.Progress != nil {
+				opts.Progress(progress)
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := <-readErr; err != nil {
+		errs = append(errs, err)
+	}
+
+	return entries, errors.Join(errs...)
+}
+
+// scanBatches reads r into batches of lines, numbered from 1 like
+// ReadDictionary does.
+func scanBatches(ctx context.Context, r io.Reader, batches chan<- loadBatch) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	b := loadBatch{firstLine: 1, lines: make([]string, 0, load_batch_lines)}
+	lineNo := 0
+
+	send := func() bool {
+		select {
+		case batches <- b:
+		case <-ctx.Done():
+			return false
+		}
+		b = loadBatch{seq: b.seq + 1, firstLine: lineNo + 1, lines: make([]string, 0, load_batch_lines)}
+		return true
+	}
+
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		b.lines = append(b.lines, line)
+		b.bytes += int64(len(line)) + 1
+
+		if len(b.lines) == load_batch_lines && !send() {
+			return ctx.Err()
+		}
+	}
+
+	if len(b.lines) > 0 {
+		send()
+	}
+	return scanner.Err()
+}
+
+func parseBatch(lineParser LineParser, b loadBatch) loadResult {
+	res := loadResult{seq: b.seq, lines: len(b.lines), bytes: b.bytes, entries: make([]Ci, 0, len(b.lines))}
+	for i, line := range b.lines {
+		l := strings.TrimSuffix(line, "\r")
+		if isSkippableLine(l) {
+			continue
+		}
+
+		ci, err := lineParser.ParseLine(l)
+		if err != nil {
+			res.errs = append(res.errs, &LineError{Line: b.firstLine + i, Err: err})
+			continue
+		}
+		res.entries = append(res.entries, ci)
+	}
+	return res
+}
@@ -0,0 +1,173 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []testItem{
+		{Name: "load_MatchesReadDictionary", Test: load_MatchesReadDictionary},
+		{Name: "load_KeepsOrder", Test: load_KeepsOrder},
+		{Name: "load_LineErrors", Test: load_LineErrors},
+		{Name: "load_Progress", Test: load_Progress},
+		{Name: "load_Cancel", Test: load_Cancel},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+// repeatedSample returns the sample dictionary n times over.
+func repeatedSample(t testing.TB, n int) []byte {
+	t.Helper()
+	data, err := os.ReadFile(sampleDictionary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Repeat(data, n)
+}
+
+func load_MatchesReadDictionary(t *testing.T) {
+	data := repeatedSample(t, 40)
+
+	expected, err := ReadDictionary(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, workers := range []int{0, 1, 3, 16} {
+		got, err := LoadDictionary(context.Background(), bytes.NewReader(data), LoadOptions{Workers: workers})
+		if err != nil {
+			t.Fatalf("%d workers: %s", workers, err.Error())
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("%d workers: entries differ from ReadDictionary", workers)
+		}
+	}
+}
+
+func load_KeepsOrder(t *testing.T) {
+	// one distinct entry per line, across many batches
+	var b strings.Builder
+	n := load_batch_lines*5 + 17
+	for i := 0; i < n; i++ {
+		b.WriteString("中 中 [zhong1] /entry ")
+		b.WriteString(strings.Repeat("x", i%7))
+		b.WriteString(string(rune('a' + i%26)))
+		b.WriteString("/\n")
+	}
+
+	expected, err := ReadDictionary(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadDictionary(context.Background(), strings.NewReader(b.String()), LoadOptions{Workers: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != n || !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %d entries in file order, got %d", n, len(got))
+	}
+}
+
+func load_LineErrors(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < load_batch_lines*2; i++ {
+		switch i {
+		case 3, load_batch_lines + 1, load_batch_lines*2 - 1:
+			b.WriteString("broken line\r\n")
+		default:
+			b.WriteString("中国 中国 [Zhong1 guo2] /China/\r\n")
+		}
+	}
+
+	expectedEntries, expectedErr := ReadDictionary(strings.NewReader(b.String()))
+	entries, err := LoadDictionary(context.Background(), strings.NewReader(b.String()), LoadOptions{Workers: 4})
+
+	if !reflect.DeepEqual(entries, expectedEntries) {
+		t.Error("entries differ from ReadDictionary")
+	}
+	if err == nil || expectedErr == nil || err.Error() != expectedErr.Error() {
+		t.Errorf("expected error %v, got %v", expectedErr, err)
+	}
+
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != 4 {
+		t.Errorf("expected the first *LineError on line 4, got %v", lineErr)
+	}
+}
+
+func load_Progress(t *testing.T) {
+	data := repeatedSample(t, 50)
+	lines := bytes.Count(data, []byte("\n"))
+
+	var reports []LoadProgress
+	entries, err := LoadDictionary(context.Background(), bytes.NewReader(data), LoadOptions{
+		Workers:  4,
+		Progress: func(p LoadProgress) { reports = append(reports, p) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reports) < 2 {
+		t.Fatalf("expected a report per batch, got %d", len(reports))
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i].Lines <= reports[i-1].Lines || reports[i].Bytes <= reports[i-1].Bytes {
+			t.Errorf("progress went backwards: %+v after %+v", reports[i], reports[i-1])
+		}
+	}
+
+	last := reports[len(reports)-1]
+	if last.Lines != lines || last.Entries != len(entries) || last.Bytes != int64(len(data)) {
+		t.Errorf("expected %d lines, %d entries, %d bytes, got %+v", lines, len(entries), len(data), last)
+	}
+}
+
+// blockingReader serves data and then blocks until unblock is closed.
+type blockingReader struct {
+	r       io.Reader
+	unblock chan struct{}
+}
+
+func (b *blockingReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err == io.EOF {
+		<-b.unblock
+	}
+	return n, err
+}
+
+func load_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := LoadDictionary(ctx, bytes.NewReader(repeatedSample(t, 10)), LoadOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// cancelled while the reader is stuck
+	r := &blockingReader{r: bytes.NewReader(repeatedSample(t, 40)), unblock: make(chan struct{})}
+	defer close(r.unblock)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	entries, err := LoadDictionary(ctx, r, LoadOptions{
+		Workers: 2,
+		Progress: func(p LoadProgress) {
+			if p.Lines >= load_batch_lines {
+				cancel()
+			}
+		},
+	})
+	if !errors.Is(err, context.Canceled) || entries != nil {
+		t.Errorf("expected context.Canceled and no entries, got %d entries and %v", len(entries), err)
+	}
+}
@@ -0,0 +1,203 @@
+package cccedictparser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PlecoCard is a flashcard of a Pleco text file: a "hanzi<TAB>pinyin<TAB>
+// definition" line, where hanzi is "simplified[traditional]" or a single form.
+type PlecoCard struct {
+	// Category is the path of the last "//" line above the card, "" if none.
+	Category    string
+	Simplified  string
+	Traditional string
+	// Pinyin is kept as written in the file, with tone marks or numbers.
+	Pinyin     string
+	Definition string
+}
+
+// ReadPleco reads a Pleco flashcard or user dictionary text file. Cards which
+// cannot be read do not stop the read; they are returned joined in the error
+// as *LineError values.
+func ReadPleco(r io.Reader) ([]PlecoCard, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var cards []PlecoCard
+	var errs []error
+	category := ""
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		l := strings.TrimSuffix(scanner.Text(), "\r")
+		if lineNo == 1 {
+			l = strings.TrimPrefix(l, "\ufeff")
+		}
+
+		if strings.HasPrefix(l, "//") {
+			category = strings.TrimSpace(strings.TrimPrefix(l, "//"))
+			continue
+		}
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+
+		fields := strings.Split(l, "\t")
+		if len(fields) < 2 || len(fields) > 3 {
+			errs = append(errs, &LineError{Line: lineNo, Err: fmt.Errorf("expected hanzi, pinyin and definition separated by tabs. Line: %s", l)})
+			continue
+		}
+
+		card := PlecoCard{Category: category, Pinyin: strings.TrimSpace(fields[1])}
+		if len(fields) == 3 {
+			card.Definition = strings.TrimSpace(fields[2])
+		}
+
+		hanzi := strings.TrimSpace(fields[0])
+		if open := strings.Index(hanzi, "["); open > 0 && strings.HasSuffix(hanzi, "]") {
+			card.Simplified = hanzi[:open]
+			card.Traditional = hanzi[open+1 : len(hanzi)-1]
+		} else {
+			card.Simplified = hanzi
+			card.Traditional = hanzi
+		}
+
+		if card.Simplified == "" {
+			errs = append(errs, &LineError{Line: lineNo, Err: fmt.Errorf("no hanzi found. Line: %s", l)})
+			continue
+		}
+
+		cards = append(cards, card)
+	}
+
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return cards, errors.Join(errs...)
+}
+
+// WritePleco writes cards as a Pleco text file, with a "//" line whenever the
+// category changes.
+func WritePleco(w io.Writer, cards []PlecoCard) error {
+	bw := bufio.NewWriter(w)
+	category := ""
+
+	for _, c := range cards {
+		if c.Category != category {
+			category = c.Category
+			fmt.Fprintf(bw, "//%s\n", category)
+		}
+
+		hanzi := c.Simplified
+		if c.Traditional != "" && c.Traditional != c.Simplified {
+			hanzi = c.Simplified + "[" + c.Traditional + "]"
+		}
+
+		if _, err := fmt.Fprintf(bw, "%s\t%s\t%s\n", hanzi, c.Pinyin, plecoField(c.Definition)); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// plecoField keeps a value on one line and out of other columns.
+func plecoField(s string) string {
+	return strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ").Replace(s)
+}
+
+// PlecoCardFromCi makes a card from a dictionary entry, with pinyin in tone
+// marks and senses joined by "; ".
+func PlecoCardFromCi(ci Ci, category string) PlecoCard {
+	return PlecoCard{
+		Category:    category,
+		Simplified:  ci.Jiantizi,
+		Traditional: ci.Fantizi,
+		Pinyin:      ci.DiacriticPinyin(),
+		Definition:  strings.Join(ci.Gloss, "; "),
+	}
+}
+
+// Ci converts the card into a dictionary entry. The pinyin is converted to
+// tone numbers and the whole entry validated by the line parser; the
+// definition is split into senses on ";".
+func (c PlecoCard) Ci() (Ci, error) {
+	numbered, err := NumberedPinyin(c.Pinyin)
+	if err != nil {
+		return Ci{}, err
+	}
+
+	var gloss []string
+	for _, v := range strings.Split(c.Definition, ";") {
+		if v = strings.TrimSpace(v); v != "" {
+			gloss = append(gloss, strings.ReplaceAll(v, "/", ","))
+		}
+	}
+	if len(gloss) == 0 {
+		return Ci{}, fmt.Errorf("no definition for card %s", c.Simplified)
+	}
+
+	trad := c.Traditional
+	if trad == "" {
+		trad = c.Simplified
+	}
+
+	return ParseLine(FormatLine(Ci{
+		Fantizi:       trad,
+		Jiantizi:      c.Simplified,
+		PinyinRaw:     numbered,
+		Gloss:         gloss,
+		FormatVersion: V1,
+	}))
+}
+
+// ResolvePleco finds the dictionary entries a card stands for: same headword
+// and same pinyin syllables and tones. Cards without pinyin match on the
+// headword alone. This lets a Pleco user dictionary be checked against, or
+// rebuilt from, CC-CEDICT.
+func ResolvePleco(idx *Index, c PlecoCard) ([]Ci, error) {
+	candidates := idx.Lookup(c.Simplified)
+	if c.Traditional != "" && c.Traditional != c.Simplified {
+		candidates = append(candidates, idx.Lookup(c.Traditional)...)
+	}
+
+	if strings.TrimSpace(c.Pinyin) == "" {
+		return dedupeCi(candidates), nil
+	}
+
+	numbered, err := NumberedPinyin(c.Pinyin)
+	if err != nil {
+		return nil, err
+	}
+	key := normalizePinyinQuery(numbered)
+	// neutral tones are often left out of numbered pinyin
+	keyNoNeutral := strings.ReplaceAll(key, "5", "")
+
+	out := make([]Ci, 0, len(candidates))
+	for _, ci := range dedupeCi(candidates) {
+		if k := tonedKey(ci); k == key || strings.ReplaceAll(k, "5", "") == keyNoNeutral {
+			out = append(out, ci)
+		}
+	}
+	return out, nil
+}
+
+func dedupeCi(cis []Ci) []Ci {
+	seen := make(map[string]bool)
+	out := make([]Ci, 0, len(cis))
+	for _, ci := range cis {
+		k := FormatLine(ci)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, ci)
+	}
+	return out
+}
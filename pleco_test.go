@@ -0,0 +1,162 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPleco(t *testing.T) {
+	tests := []testItem{
+		{Name: "pleco_Read", Test: pleco_Read},
+		{Name: "pleco_Write", Test: pleco_Write},
+		{Name: "pleco_Ci", Test: pleco_Ci},
+		{Name: "pleco_Resolve", Test: pleco_Resolve},
+		{Name: "pleco_RoundTrip", Test: pleco_RoundTrip},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+const pleco_sample = "//HSK/Level 1\n" +
+	"中国\tZhōngguó\tChina\n" +
+	"学习[學習]\txue2xi2\tto learn; to study\n" +
+	"\n" +
+	"//Food\n" +
+	"餐馆[餐館]\tcānguǎn\trestaurant\n" +
+	"broken line\n"
+
+func pleco_Read(t *testing.T) {
+	cards, err := ReadPleco(strings.NewReader(pleco_sample))
+
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != 7 {
+		t.Errorf("expected an error on line 7, got %v", err)
+	}
+
+	expected := []PlecoCard{
+		{Category: "HSK/Level 1", Simplified: "中国", Traditional: "中国", Pinyin: "Zhōngguó", Definition: "China"},
+		{Category: "HSK/Level 1", Simplified: "学习", Traditional: "學習", Pinyin: "xue2xi2", Definition: "to learn; to study"},
+		{Category: "Food", Simplified: "餐馆", Traditional: "餐館", Pinyin: "cānguǎn", Definition: "restaurant"},
+	}
+	if len(cards) != len(expected) {
+		t.Fatalf("expected %d cards, got %d", len(expected), len(cards))
+	}
+	for i := range expected {
+		if cards[i] != expected[i] {
+			t.Errorf("card %d: expected %+v, got %+v", i, expected[i], cards[i])
+		}
+	}
+}
+
+func pleco_Write(t *testing.T) {
+	cards := []PlecoCard{
+		{Simplified: "你好", Traditional: "你好", Pinyin: "nǐ hǎo", Definition: "hello"},
+		{Category: "Food", Simplified: "餐馆", Traditional: "餐館", Pinyin: "cān guǎn", Definition: "restaurant\tshop"},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePleco(&buf, cards); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "你好\tnǐ hǎo\thello\n//Food\n餐馆[餐館]\tcān guǎn\trestaurant shop\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func pleco_Ci(t *testing.T) {
+	card := PlecoCard{Simplified: "学习", Traditional: "學習", Pinyin: "xuéxí", Definition: "to learn; to study"}
+	ci, err := card.Ci()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := FormatLine(ci); got != "學習 学习 [xue2 xi2] /to learn/to study/" {
+		t.Errorf("unexpected entry %s", got)
+	}
+
+	if _, err := (PlecoCard{Simplified: "学", Pinyin: "xué"}).Ci(); err == nil {
+		t.Errorf("expected an error for a card without definition")
+	}
+	if _, err := (PlecoCard{Simplified: "学", Pinyin: "xuéqqq", Definition: "x"}).Ci(); err == nil {
+		t.Errorf("expected an error for a card with bad pinyin")
+	}
+}
+
+func pleco_Resolve(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	cases := []struct {
+		card     PlecoCard
+		expected []string
+	}{
+		{PlecoCard{Simplified: "中", Pinyin: "zhòng"}, []string{"中 中 [zhong4] /to hit (the mark)/to be hit by/to suffer/to win (a prize, a lottery)/"}},
+		{PlecoCard{Simplified: "学生", Traditional: "學生", Pinyin: "xuésheng"}, []string{"學生 学生 [xue2 sheng5] /student/schoolchild/"}},
+		{PlecoCard{Simplified: "学生", Pinyin: "xue2sheng"}, []string{"學生 学生 [xue2 sheng5] /student/schoolchild/"}},
+		{PlecoCard{Simplified: "好", Pinyin: ""}, []string{
+			"好 好 [hao3] /good/appropriate; proper/all right!/(before a verb) easy to/(before a verb) good to/(after a personal pronoun) hello/",
+			"好 好 [hao4] /to be fond of/to have a tendency to/to be prone to/",
+		}},
+		{PlecoCard{Simplified: "好", Pinyin: "hāo"}, nil},
+	}
+
+	for _, v := range cases {
+		got, err := ResolvePleco(idx, v.card)
+		if err != nil {
+			t.Errorf("%s: %s", v.card.Simplified, err.Error())
+			continue
+		}
+		if len(got) != len(v.expected) {
+			t.Errorf("%s %s: expected %d entries, got %d", v.card.Simplified, v.card.Pinyin, len(v.expected), len(got))
+			continue
+		}
+		for i := range got {
+			if FormatLine(got[i]) != v.expected[i] {
+				t.Errorf("%s %s: expected %s, got %s", v.card.Simplified, v.card.Pinyin, v.expected[i], FormatLine(got[i]))
+			}
+		}
+	}
+}
+
+func pleco_RoundTrip(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	cards := make([]PlecoCard, 0, idx.Len())
+	for _, ci := range idx.Entries() {
+		cards = append(cards, PlecoCardFromCi(ci, "Sample"))
+	}
+
+	var buf bytes.Buffer
+	if err := WritePleco(&buf, cards); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadPleco(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(cards) {
+		t.Fatalf("expected %d cards, got %d", len(cards), len(read))
+	}
+
+	for i, card := range read {
+		entry := idx.Entry(i)
+		found, err := ResolvePleco(idx, card)
+		if err != nil {
+			t.Errorf("%s: %s", card.Simplified, err.Error())
+			continue
+		}
+
+		ok := false
+		for _, ci := range found {
+			ok = ok || FormatLine(ci) == FormatLine(entry)
+		}
+		if !ok {
+			t.Errorf("%s: entry not resolved from card %+v", FormatLine(entry), card)
+		}
+	}
+}
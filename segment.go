@@ -0,0 +1,113 @@
+package cccedictparser
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// segment_unknown_cost is the cost of a character not covered by a word, so
+// any split into dictionary words is preferred.
+const segment_unknown_cost = 3
+
+// Segment is a piece of segmented text: a dictionary word or a run of text
+// not in the dictionary.
+type Segment struct {
+	Text string `json:"text"`
+	// Start and End are the byte offsets of Text in the segmented text.
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Entries are the dictionary entries of Text, none when it is not a word.
+	Entries []Ci `json:"entries"`
+}
+
+func (idx *Index) buildMaxWordLen() {
+	for _, ci := range idx.entries {
+		idx.maxWordLen = max(idx.maxWordLen, utf8.RuneCountInString(ci.Jiantizi), utf8.RuneCountInString(ci.Fantizi))
+	}
+}
+
+// runeClass groups the characters merged into one segment when they are not
+// in the dictionary: letters and digits, spaces, and everything else.
+// Han characters are never merged.
+func runeClass(r rune) int {
+	switch {
+	case unicode.Is(unicode.Han, r):
+		return 0
+	case unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 1
+	case unicode.IsSpace(r):
+		return 2
+	}
+	return 3
+}
+
+// Segment splits text into dictionary words, simplified or traditional,
+// using as few words as possible. Text which is not in the dictionary, such
+// as punctuation or Latin words, is returned in segments without entries.
+func (idx *Index) Segment(text string) []Segment {
+	idx.segmentOnce.Do(idx.buildMaxWordLen)
+
+	// byte offset of every rune, plus the end
+	offsets := make([]int, 0, len(text)+1)
+	for i := range text {
+		offsets = append(offsets, i)
+	}
+	n := len(offsets)
+	offsets = append(offsets, len(text))
+
+	// cost[p] is the cheapest way to segment the first p runes, from[p] the
+	// start of the last segment
+	cost := make([]int, n+1)
+	from := make([]int, n+1)
+	for i := range cost {
+		cost[i] = -1
+	}
+	cost[0] = 0
+
+	for p := 0; p < n; p++ {
+		for l := min(idx.maxWordLen, n-p); l >= 1; l-- {
+			word := text[offsets[p]:offsets[p+l]]
+			c := segment_unknown_cost
+			if len(idx.bySimplified[word]) > 0 || len(idx.byTraditional[word]) > 0 {
+				c = 1
+			} else if l > 1 {
+				continue
+			}
+
+			if cost[p+l] < 0 || cost[p]+c < cost[p+l] {
+				cost[p+l] = cost[p] + c
+				from[p+l] = p
+			}
+		}
+	}
+
+	var bounds []int
+	for p := n; p > 0; p = from[p] {
+		bounds = append(bounds, p)
+	}
+
+	out := make([]Segment, 0, len(bounds))
+	start := 0
+	for i := len(bounds) - 1; i >= 0; i-- {
+		end := bounds[i]
+		s := Segment{Text: text[offsets[start]:offsets[end]], Start: offsets[start], End: offsets[end]}
+		s.Entries = idx.Lookup(s.Text)
+
+		// merge runs of unknown characters of the same class
+		if len(s.Entries) == 0 && len(out) > 0 {
+			prev := &out[len(out)-1]
+			a, _ := utf8.DecodeLastRuneInString(prev.Text)
+			b, _ := utf8.DecodeRuneInString(s.Text)
+			if len(prev.Entries) == 0 && runeClass(a) == runeClass(b) && runeClass(a) != 0 {
+				prev.Text += s.Text
+				prev.End = s.End
+				start = end
+				continue
+			}
+		}
+
+		out = append(out, s)
+		start = end
+	}
+	return out
+}
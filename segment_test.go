@@ -0,0 +1,65 @@
+package cccedictparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSegment(t *testing.T) {
+	tests := []testItem{
+		{Name: "segment_Words", Test: segment_Words},
+		{Name: "segment_Offsets", Test: segment_Offsets},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func segmentString(segments []Segment) string {
+	parts := make([]string, 0, len(segments))
+	for _, s := range segments {
+		if len(s.Entries) == 0 {
+			parts = append(parts, "?"+s.Text)
+		} else {
+			parts = append(parts, s.Text)
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+func segment_Words(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	cases := []testCase[string]{
+		{Sentence: "我们是大学生。", Expected: "我们|是|大学生|?。"},
+		{Sentence: "我們是學生", Expected: "我們|是|學生"},
+		{Sentence: "中国人", Expected: "中国人"},
+		{Sentence: "我爱你", Expected: "我|?爱|你"},
+		{Sentence: "你好, Bob42!", Expected: "你好|?,|? |?Bob42|?!"},
+		{Sentence: "卡拉OK很好", Expected: "卡拉OK|?很|好"},
+		{Sentence: "", Expected: ""},
+	}
+
+	for _, v := range cases {
+		if got := segmentString(idx.Segment(v.Sentence)); got != v.Expected {
+			t.Errorf("%s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
+
+func segment_Offsets(t *testing.T) {
+	idx := loadSampleIndex(t)
+	text := "Hi 老虎和老鼠"
+
+	end := 0
+	for _, s := range idx.Segment(text) {
+		if s.Start != end || text[s.Start:s.End] != s.Text {
+			t.Errorf("bad offsets for %+v", s)
+		}
+		end = s.End
+	}
+	if end != len(text) {
+		t.Errorf("segments end at %d, expected %d", end, len(text))
+	}
+}
@@ -0,0 +1,721 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"slices"
+	"strings"
+)
+
+// SnapshotVersion is the format version written by WriteSnapshot. Snapshots
+// of other versions are rejected with ErrSnapshotVersion.
+const SnapshotVersion = 1
+
+var (
+	// ErrSnapshotFormat is returned for data which is not a snapshot or is
+	// truncated or inconsistent.
+	ErrSnapshotFormat = errors.New("malformed snapshot")
+	// ErrSnapshotVersion is returned for snapshots of another format version.
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+	// ErrSnapshotChecksum is returned when a checksum does not match.
+	ErrSnapshotChecksum = errors.New("snapshot checksum mismatch")
+)
+
+// A snapshot is little endian:
+//
+//	header     magic "CCEDSNAP", version u16, section count u16, entry count u32
+//	directory  per section: id u32, crc32c u32, offset u64, length u64
+//	           crc32c u32 of the header and directory
+//	sections   8-byte aligned, in any order
+//
+// Sections hold fixed-size records referring to each other by index:
+//
+//	strings        count u32, count+1 offsets u32, then the interned text
+//	literals       string ids u32 of the syllable sounds not in full_pinyin_list
+//	entries        per entry (32 bytes): traditional, simplified and raw
+//	               pinyin string ids, first sense, first word and first
+//	               syllable u32, sense count u16, word count u16, version u8
+//	words          syllable count u16 per word
+//	syllables      packed syllables u16, see packSyllable
+//	senses         string ids u32
+//	by_simplified, by_traditional, by_pinyin, by_toneless
+//	               entry ids u32 sorted by the key, then by id
+const snapshot_magic = "CCEDSNAP"
+
+const (
+	snapshot_header_len    = 16
+	snapshot_directory_len = 24
+	snapshot_entry_len     = 32
+)
+
+const (
+	snapshot_section_strings = iota + 1
+	snapshot_section_literals
+	snapshot_section_entries
+	snapshot_section_words
+	snapshot_section_syllables
+	snapshot_section_senses
+	snapshot_section_by_simplified
+	snapshot_section_by_traditional
+	snapshot_section_by_pinyin
+	snapshot_section_by_toneless
+	snapshot_section_count = snapshot_section_by_toneless
+)
+
+var snapshot_crc = crc32.MakeTable(crc32.Castagnoli)
+
+// Packed syllables: bits 0-8 index full_pinyin_list, or the literals when
+// bit 9 is set; bit 10 capitalizes the first letter of a full_pinyin_list
+// sound; bits 11-13 are the tone and bits 14-15 the type.
+const (
+	syllable_index_mask = 1<<9 - 1
+	syllable_literal    = 1 << 9
+	syllable_capital    = 1 << 10
+	syllable_tone_shift = 11
+	syllable_type_shift = 14
+)
+
+var pinyin_syllable_ids = func() map[string]uint16 {
+	ids := make(map[string]uint16, len(full_pinyin_list))
+	for i, v := range full_pinyin_list {
+		ids[v] = uint16(i)
+	}
+	return ids
+}()
+
+var capitalized_pinyin_list = func() []string {
+	out := make([]string, len(full_pinyin_list))
+	for i, v := range full_pinyin_list {
+		out[i] = strings.ToUpper(v[:1]) + v[1:]
+	}
+	return out
+}()
+
+func snapshotVersionByte(v FormatVersion) byte {
+	switch v {
+	case V1:
+		return 1
+	case V2:
+		return 2
+	default:
+		return 0
+	}
+}
+
+var snapshot_versions = []FormatVersion{"", V1, V2}
+
+// snapshotBuilder interns the strings and syllable sounds of a snapshot.
+type snapshotBuilder struct {
+	ids      map[string]uint32
+	offsets  []byte
+	text     []byte
+	count    uint32
+	literals map[string]uint16
+	literal  []byte
+}
+
+func (sb *snapshotBuilder) intern(s string) uint32 {
+	if id, ok := sb.ids[s]; ok {
+		return id
+	}
+	id := sb.count
+	sb.ids[s] = id
+	sb.count++
+	sb.text = append(sb.text, s...)
+	sb.offsets = binary.LittleEndian.AppendUint32(sb.offsets, uint32(len(sb.text)))
+	return id
+}
+
+// packSyllable packs p, interning its sound when it is not a plain or
+// capitalized member of full_pinyin_list.
+func (sb *snapshotBuilder) packSyllable(p PinyinV1) (uint16, error) {
+	packed := uint16(p.Tone)<<syllable_tone_shift | uint16(p.Type)<<syllable_type_shift
+	if p.Tone > T5 || p.Type > Special {
+		return 0, fmt.Errorf("syllable %s: tone or type out of range", p.Sound)
+	}
+
+	if id, ok := pinyin_syllable_ids[p.Sound]; ok {
+		return packed | id, nil
+	}
+	if id, ok := pinyin_syllable_ids[strings.ToLower(p.Sound)]; ok && capitalized_pinyin_list[id] == p.Sound {
+		return packed | syllable_capital | id, nil
+	}
+
+	id, ok := sb.literals[p.Sound]
+	if !ok {
+		if len(sb.literals) > syllable_index_mask {
+			return 0, errors.New("too many distinct syllables outside the pinyin list for a snapshot")
+		}
+		id = uint16(len(sb.literals))
+		sb.literals[p.Sound] = id
+		sb.literal = binary.LittleEndian.AppendUint32(sb.literal, sb.intern(p.Sound))
+	}
+	return packed | syllable_literal | id, nil
+}
+
+// WriteSnapshot writes entries as a binary snapshot, which NewSnapshot,
+// OpenSnapshot and ReadSnapshot load without parsing text. Strings are
+// interned, syllables are packed in two bytes and the lookups of Index are
+// stored presorted.
+func WriteSnapshot(w io.Writer, entries []Ci) error {
+	if len(entries) > math.MaxUint32 {
+		return errors.New("too many entries for a snapshot")
+	}
+
+	sb := &snapshotBuilder{
+		ids:      make(map[string]uint32),
+		offsets:  binary.LittleEndian.AppendUint32(nil, 0),
+		literals: make(map[string]uint16),
+	}
+
+	var records, words, syllables, senses []byte
+	nWords, nSyllables, nSenses := 0, 0, 0
+
+	for _, ci := range entries {
+		if len(ci.Gloss) > math.MaxUint16 || len(ci.Pinyin) > math.MaxUint16 {
+			return fmt.Errorf("entry %s has too many senses or words for a snapshot", ci.Jiantizi)
+		}
+
+		records = binary.LittleEndian.AppendUint32(records, sb.intern(ci.Fantizi))
+		records = binary.LittleEndian.AppendUint32(records, sb.intern(ci.Jiantizi))
+		records = binary.LittleEndian.AppendUint32(records, sb.intern(ci.PinyinRaw))
+		records = binary.LittleEndian.AppendUint32(records, uint32(nSenses))
+		records = binary.LittleEndian.AppendUint32(records, uint32(nWords))
+		records = binary.LittleEndian.AppendUint32(records, uint32(nSyllables))
+		records = binary.LittleEndian.AppendUint16(records, uint16(len(ci.Gloss)))
+		records = binary.LittleEndian.AppendUint16(records, uint16(len(ci.Pinyin)))
+		records = append(records, snapshotVersionByte(ci.FormatVersion), 0, 0, 0)
+
+		for _, word := range ci.Pinyin {
+			if len(word.Word) > math.MaxUint16 {
+				return fmt.Errorf("entry %s has too many syllables for a snapshot", ci.Jiantizi)
+			}
+			words = binary.LittleEndian.AppendUint16(words, uint16(len(word.Word)))
+			nWords++
+
+			for _, p := range word.Word {
+				packed, err := sb.packSyllable(p)
+				if err != nil {
+					return fmt.Errorf("entry %s: %w", ci.Jiantizi, err)
+				}
+				syllables = binary.LittleEndian.AppendUint16(syllables, packed)
+				nSyllables++
+			}
+		}
+
+		for _, sense := range ci.Gloss {
+			senses = binary.LittleEndian.AppendUint32(senses, sb.intern(sense))
+			nSenses++
+		}
+	}
+
+	if sb.count == math.MaxUint32 || len(sb.text) > math.MaxUint32 || nSyllables > math.MaxUint32 {
+		return errors.New("dictionary too large for a snapshot")
+	}
+
+	strs := binary.LittleEndian.AppendUint32(nil, sb.count)
+	strs = append(strs, sb.offsets...)
+	strs = append(strs, sb.text...)
+
+	sortedBy := func(key func(Ci) string) []byte {
+		keys := make([]string, len(entries))
+		order := make([]uint32, len(entries))
+		for i, ci := range entries {
+			keys[i] = key(ci)
+			order[i] = uint32(i)
+		}
+		slices.SortStableFunc(order, func(a, b uint32) int {
+			return strings.Compare(keys[a], keys[b])
+		})
+
+		out := make([]byte, 0, 4*len(order))
+		for _, i := range order {
+			out = binary.LittleEndian.AppendUint32(out, i)
+		}
+		return out
+	}
+
+	sections := [][]byte{
+		snapshot_section_strings:        strs,
+		snapshot_section_literals:       sb.literal,
+		snapshot_section_entries:        records,
+		snapshot_section_words:          words,
+		snapshot_section_syllables:      syllables,
+		snapshot_section_senses:         senses,
+		snapshot_section_by_simplified:  sortedBy(func(ci Ci) string { return ci.Jiantizi }),
+		snapshot_section_by_traditional: sortedBy(func(ci Ci) string { return ci.Fantizi }),
+		snapshot_section_by_pinyin:      sortedBy(tonedKey),
+		snapshot_section_by_toneless:    sortedBy(tonelessKey),
+	}
+
+	headerLen := snapshot_header_len + snapshot_section_count*snapshot_directory_len + 4
+	header := make([]byte, 0, headerLen)
+	header = append(header, snapshot_magic...)
+	header = binary.LittleEndian.AppendUint16(header, SnapshotVersion)
+	header = binary.LittleEndian.AppendUint16(header, snapshot_section_count)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(entries)))
+
+	offset := align8(headerLen)
+	for id := 1; id <= snapshot_section_count; id++ {
+		header = binary.LittleEndian.AppendUint32(header, uint32(id))
+		header = binary.LittleEndian.AppendUint32(header, crc32.Checksum(sections[id], snapshot_crc))
+		header = binary.LittleEndian.AppendUint64(header, uint64(offset))
+		header = binary.LittleEndian.AppendUint64(header, uint64(len(sections[id])))
+		offset = align8(offset + len(sections[id]))
+	}
+	header = binary.LittleEndian.AppendUint32(header, crc32.Checksum(header, snapshot_crc))
+
+	var padding [8]byte
+	written := len(header)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	for id := 1; id <= snapshot_section_count; id++ {
+		if _, err := w.Write(padding[:align8(written)-written]); err != nil {
+			return err
+		}
+		if _, err := w.Write(sections[id]); err != nil {
+			return err
+		}
+		written = align8(written) + len(sections[id])
+	}
+	return nil
+}
+
+func align8(n int) int {
+	return (n + 7) &^ 7
+}
+
+// Snapshot is a dictionary snapshot read in place. Entries are decoded on
+// demand and lookups binary search the presorted orders, so opening a
+// snapshot does not depend on the size of the dictionary beyond checking it.
+type Snapshot struct {
+	count      int
+	strOffsets []byte
+	strText    []byte
+	literals   []byte
+	entries    []byte
+	words      []byte
+	syllables  []byte
+	senses     []byte
+
+	bySimplified  []byte
+	byTraditional []byte
+	byPinyin      []byte
+	byToneless    []byte
+
+	close func() error
+}
+
+type snapshotEntry struct {
+	fantizi, jiantizi, pinyinRaw uint32
+	sense, word, syllable        int
+	senseCount, wordCount        int
+	version                      byte
+}
+
+// NewSnapshot reads a snapshot written by WriteSnapshot. data is retained and
+// must not be modified. The header, the checksums and every reference between
+// sections are checked, so the other methods cannot fail.
+func NewSnapshot(data []byte) (*Snapshot, error) {
+	le := binary.LittleEndian
+
+	if len(data) < snapshot_header_len || string(data[:8]) != snapshot_magic {
+		return nil, fmt.Errorf("%w: no snapshot header", ErrSnapshotFormat)
+	}
+	if v := le.Uint16(data[8:]); v != SnapshotVersion {
+		return nil, fmt.Errorf("%w: %d, expected %d", ErrSnapshotVersion, v, SnapshotVersion)
+	}
+
+	nSections := int(le.Uint16(data[10:]))
+	dirEnd := snapshot_header_len + nSections*snapshot_directory_len
+	if len(data) < dirEnd+4 {
+		return nil, fmt.Errorf("%w: truncated directory", ErrSnapshotFormat)
+	}
+	if crc32.Checksum(data[:dirEnd], snapshot_crc) != le.Uint32(data[dirEnd:]) {
+		return nil, fmt.Errorf("%w: header", ErrSnapshotChecksum)
+	}
+
+	sections := make([][]byte, snapshot_section_count+1)
+	for i := 0; i < nSections; i++ {
+		d := data[snapshot_header_len+i*snapshot_directory_len:]
+		id := le.Uint32(d)
+		offset, length := le.Uint64(d[8:]), le.Uint64(d[16:])
+		if offset > uint64(len(data)) || length > uint64(len(data))-offset {
+			return nil, fmt.Errorf("%w: section %d out of bounds", ErrSnapshotFormat, id)
+		}
+		// sections unknown to this version are skipped
+		if id == 0 || id > snapshot_section_count {
+			continue
+		}
+		section := data[offset : offset+length]
+		if crc32.Checksum(section, snapshot_crc) != le.Uint32(d[4:]) {
+			return nil, fmt.Errorf("%w: section %d", ErrSnapshotChecksum, id)
+		}
+		sections[id] = section
+	}
+	for id := 1; id <= snapshot_section_count; id++ {
+		if sections[id] == nil {
+			return nil, fmt.Errorf("%w: missing section %d", ErrSnapshotFormat, id)
+		}
+	}
+
+	s := &Snapshot{
+		count:         int(le.Uint32(data[12:])),
+		literals:      sections[snapshot_section_literals],
+		entries:       sections[snapshot_section_entries],
+		words:         sections[snapshot_section_words],
+		syllables:     sections[snapshot_section_syllables],
+		senses:        sections[snapshot_section_senses],
+		bySimplified:  sections[snapshot_section_by_simplified],
+		byTraditional: sections[snapshot_section_by_traditional],
+		byPinyin:      sections[snapshot_section_by_pinyin],
+		byToneless:    sections[snapshot_section_by_toneless],
+	}
+
+	strs := sections[snapshot_section_strings]
+	if len(strs) < 8 {
+		return nil, fmt.Errorf("%w: strings", ErrSnapshotFormat)
+	}
+	nStrings := uint64(le.Uint32(strs))
+	if uint64(len(strs)-4)/4 <= nStrings {
+		return nil, fmt.Errorf("%w: strings", ErrSnapshotFormat)
+	}
+	s.strOffsets = strs[4 : 4+4*(nStrings+1)]
+	s.strText = strs[4+4*(nStrings+1):]
+
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// check verifies that every reference of the snapshot is in range.
+func (s *Snapshot) check() error {
+	le := binary.LittleEndian
+	bad := func(what string) error {
+		return fmt.Errorf("%w: %s", ErrSnapshotFormat, what)
+	}
+
+	nStrings := uint32(len(s.strOffsets)/4 - 1)
+	prev := uint32(0)
+	for i := 0; i < len(s.strOffsets); i += 4 {
+		off := le.Uint32(s.strOffsets[i:])
+		if off < prev || int(off) > len(s.strText) {
+			return bad("string offsets")
+		}
+		prev = off
+	}
+
+	if len(s.literals)%4 != 0 || len(s.words)%2 != 0 || len(s.syllables)%2 != 0 || len(s.senses)%4 != 0 {
+		return bad("section sizes")
+	}
+	for i := 0; i < len(s.literals); i += 4 {
+		if le.Uint32(s.literals[i:]) >= nStrings {
+			return bad("literals")
+		}
+	}
+	for i := 0; i < len(s.senses); i += 4 {
+		if le.Uint32(s.senses[i:]) >= nStrings {
+			return bad("senses")
+		}
+	}
+
+	nLiterals := uint16(len(s.literals) / 4)
+	for i := 0; i < len(s.syllables); i += 2 {
+		v := le.Uint16(s.syllables[i:])
+		id := v & syllable_index_mask
+		if v&syllable_literal != 0 && (id >= nLiterals || v&syllable_capital != 0) ||
+			v&syllable_literal == 0 && int(id) >= len(full_pinyin_list) ||
+			Tone(v>>syllable_tone_shift&7) > T5 {
+			return bad("syllables")
+		}
+	}
+
+	if uint64(len(s.entries)) != uint64(s.count)*snapshot_entry_len {
+		return bad("entry count")
+	}
+	nWords, nSyllables, nSenses := len(s.words)/2, len(s.syllables)/2, len(s.senses)/4
+	for i := 0; i < s.count; i++ {
+		e := s.entry(i)
+		if e.fantizi >= nStrings || e.jiantizi >= nStrings || e.pinyinRaw >= nStrings ||
+			e.version >= byte(len(snapshot_versions)) ||
+			e.sense > nSenses-e.senseCount || e.word > nWords-e.wordCount {
+			return bad(fmt.Sprintf("entry %d", i))
+		}
+		n := 0
+		for w := e.word; w < e.word+e.wordCount; w++ {
+			n += int(le.Uint16(s.words[2*w:]))
+		}
+		if e.syllable > nSyllables-n {
+			return bad(fmt.Sprintf("entry %d", i))
+		}
+	}
+
+	for _, order := range [][]byte{s.bySimplified, s.byTraditional, s.byPinyin, s.byToneless} {
+		if len(order) != len(s.entries)/snapshot_entry_len*4 {
+			return bad("lookup order size")
+		}
+		for i := 0; i < len(order); i += 4 {
+			if int(le.Uint32(order[i:])) >= s.count {
+				return bad("lookup order")
+			}
+		}
+	}
+	return nil
+}
+
+// OpenSnapshot memory maps the snapshot file at path, where the platform
+// allows it, and reads it with NewSnapshot. Close releases the mapping.
+func OpenSnapshot(path string) (*Snapshot, error) {
+	data, closeFn, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := NewSnapshot(data)
+	if err != nil {
+		closeFn()
+		return nil, err
+	}
+	s.close = closeFn
+	return s, nil
+}
+
+// ReadSnapshot reads a whole snapshot and decodes its entries. Equal strings
+// are shared between entries.
+func ReadSnapshot(r io.Reader) ([]Ci, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewSnapshot(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.Entries(), nil
+}
+
+// Close releases the file of OpenSnapshot. Decoded entries stay valid.
+func (s *Snapshot) Close() error {
+	if s.close == nil {
+		return nil
+	}
+	err := s.close()
+	s.close = nil
+	return err
+}
+
+// Len returns the number of entries.
+func (s *Snapshot) Len() int {
+	return s.count
+}
+
+func (s *Snapshot) str(id uint32) []byte {
+	start := binary.LittleEndian.Uint32(s.strOffsets[4*id:])
+	end := binary.LittleEndian.Uint32(s.strOffsets[4*id+4:])
+	return s.strText[start:end]
+}
+
+func (s *Snapshot) entry(i int) snapshotEntry {
+	le := binary.LittleEndian
+	r := s.entries[i*snapshot_entry_len:]
+	return snapshotEntry{
+		fantizi:    le.Uint32(r),
+		jiantizi:   le.Uint32(r[4:]),
+		pinyinRaw:  le.Uint32(r[8:]),
+		sense:      int(le.Uint32(r[12:])),
+		word:       int(le.Uint32(r[16:])),
+		syllable:   int(le.Uint32(r[20:])),
+		senseCount: int(le.Uint16(r[24:])),
+		wordCount:  int(le.Uint16(r[26:])),
+		version:    r[28],
+	}
+}
+
+// sound returns the sound of a packed syllable, str decoding literals.
+func (s *Snapshot) sound(v uint16, str func(uint32) string) string {
+	id := v & syllable_index_mask
+	switch {
+	case v&syllable_literal != 0:
+		return str(binary.LittleEndian.Uint32(s.literals[4*int(id):]))
+	case v&syllable_capital != 0:
+		return capitalized_pinyin_list[id]
+	default:
+		return full_pinyin_list[id]
+	}
+}
+
+// decode builds the i-th entry, str turning string ids into strings.
+func (s *Snapshot) decode(i int, str func(uint32) string) Ci {
+	le := binary.LittleEndian
+	e := s.entry(i)
+
+	ci := Ci{
+		Fantizi:       str(e.fantizi),
+		Jiantizi:      str(e.jiantizi),
+		PinyinRaw:     str(e.pinyinRaw),
+		FormatVersion: snapshot_versions[e.version],
+	}
+
+	if e.senseCount > 0 {
+		ci.Gloss = make([]string, e.senseCount)
+		for k := range ci.Gloss {
+			ci.Gloss[k] = str(le.Uint32(s.senses[4*(e.sense+k):]))
+		}
+	}
+
+	if e.wordCount > 0 {
+		n := 0
+		for w := e.word; w < e.word+e.wordCount; w++ {
+			n += int(le.Uint16(s.words[2*w:]))
+		}
+
+		syllables := make([]PinyinV1, n)
+		for k := range syllables {
+			v := le.Uint16(s.syllables[2*(e.syllable+k):])
+			syllables[k] = PinyinV1{
+				Sound: s.sound(v, str),
+				Tone:  Tone(v >> syllable_tone_shift & 7),
+				Type:  PinyinType(v >> syllable_type_shift),
+			}
+		}
+
+		ci.Pinyin = make([]PinyinV2, e.wordCount)
+		start := 0
+		for k := range ci.Pinyin {
+			end := start + int(le.Uint16(s.words[2*(e.word+k):]))
+			ci.Pinyin[k] = PinyinV2{Word: syllables[start:end:end]}
+			start = end
+		}
+	}
+
+	return ci
+}
+
+// Ci decodes the i-th entry, copying its text out of the snapshot.
+func (s *Snapshot) Ci(i int) Ci {
+	return s.decode(i, func(id uint32) string { return string(s.str(id)) })
+}
+
+// Entries decodes every entry. Each distinct string is copied once and shared
+// by the entries using it.
+func (s *Snapshot) Entries() []Ci {
+	strs := make([]string, len(s.strOffsets)/4-1)
+	done := make([]bool, len(strs))
+	str := func(id uint32) string {
+		if !done[id] {
+			strs[id] = string(s.str(id))
+			done[id] = true
+		}
+		return strs[id]
+	}
+
+	out := make([]Ci, s.count)
+	for i := range out {
+		out[i] = s.decode(i, str)
+	}
+	return out
+}
+
+// key appends the pinyin key of the i-th entry to buf, as tonedKey or
+// tonelessKey would build it.
+func (s *Snapshot) key(buf []byte, i int, toned bool) []byte {
+	le := binary.LittleEndian
+	e := s.entry(i)
+	str := func(id uint32) string { return string(s.str(id)) }
+
+	n := 0
+	for w := e.word; w < e.word+e.wordCount; w++ {
+		n += int(le.Uint16(s.words[2*w:]))
+	}
+	for k := 0; k < n; k++ {
+		v := le.Uint16(s.syllables[2*(e.syllable+k):])
+		if PinyinType(v>>syllable_type_shift) == Special {
+			continue
+		}
+		if v&syllable_literal != 0 {
+			buf = append(buf, strings.ToLower(s.sound(v, str))...)
+		} else {
+			buf = append(buf, full_pinyin_list[v&syllable_index_mask]...)
+		}
+		if tone := Tone(v >> syllable_tone_shift & 7); toned && tone != None {
+			buf = append(buf, '0'+tone)
+		}
+	}
+	return buf
+}
+
+// find returns the ids in order whose key is word, key appending the key of
+// an entry to a buffer.
+func (s *Snapshot) find(order []byte, word string, key func(buf []byte, i int) []byte) []int {
+	w := []byte(word)
+	n := len(order) / 4
+	id := func(k int) int { return int(binary.LittleEndian.Uint32(order[4*k:])) }
+
+	var buf []byte
+	cmp := func(k int) int {
+		buf = key(buf[:0], id(k))
+		return bytes.Compare(buf, w)
+	}
+
+	lo, hi := 0, n
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if cmp(mid) < 0 {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	var out []int
+	for k := lo; k < n && cmp(k) == 0; k++ {
+		out = append(out, id(k))
+	}
+	return out
+}
+
+func (s *Snapshot) collect(ids ...[]int) []Ci {
+	seen := make(map[int]bool)
+	out := make([]Ci, 0)
+	for _, list := range ids {
+		for _, i := range list {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			out = append(out, s.Ci(i))
+		}
+	}
+	return out
+}
+
+// Lookup returns the entries whose simplified or traditional headword is
+// word, like Index.Lookup.
+func (s *Snapshot) Lookup(word string) []Ci {
+	simplified := s.find(s.bySimplified, word, func(buf []byte, i int) []byte {
+		return append(buf, s.str(s.entry(i).jiantizi)...)
+	})
+	traditional := s.find(s.byTraditional, word, func(buf []byte, i int) []byte {
+		return append(buf, s.str(s.entry(i).fantizi)...)
+	})
+	return s.collect(simplified, traditional)
+}
+
+// LookupPinyin returns the entries read as pinyin, like Index.LookupPinyin.
+func (s *Snapshot) LookupPinyin(pinyin string) []Ci {
+	q := normalizePinyinQuery(pinyin)
+	if q == "" {
+		return []Ci{}
+	}
+	if strings.ContainsAny(q, "12345") {
+		return s.collect(s.find(s.byPinyin, q, func(buf []byte, i int) []byte { return s.key(buf, i, true) }))
+	}
+	return s.collect(s.find(s.byToneless, q, func(buf []byte, i int) []byte { return s.key(buf, i, false) }))
+}
@@ -0,0 +1,205 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	tests := []testItem{
+		{Name: "snapshot_RoundTrip", Test: snapshot_RoundTrip},
+		{Name: "snapshot_Syllables", Test: snapshot_Syllables},
+		{Name: "snapshot_Interning", Test: snapshot_Interning},
+		{Name: "snapshot_Lookup", Test: snapshot_Lookup},
+		{Name: "snapshot_Errors", Test: snapshot_Errors},
+		{Name: "snapshot_Open", Test: snapshot_Open},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func writeSnapshot(t *testing.T, entries []Ci) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func snapshot_RoundTrip(t *testing.T) {
+	entries := loadSample(t)
+	data := writeSnapshot(t, entries)
+
+	got, err := ReadSnapshot(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, entries) {
+		t.Error("entries read back differ")
+	}
+
+	s, err := NewSnapshot(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != len(entries) {
+		t.Fatalf("expected %d entries, got %d", len(entries), s.Len())
+	}
+	for i, ci := range entries {
+		if got := s.Ci(i); !reflect.DeepEqual(got, ci) {
+			t.Errorf("entry %d:\nexpected %v\ngot      %v", i, ci, got)
+		}
+	}
+
+	empty, err := ReadSnapshot(bytes.NewReader(writeSnapshot(t, nil)))
+	if err != nil || len(empty) != 0 {
+		t.Errorf("expected an empty dictionary, got %d entries (%v)", len(empty), err)
+	}
+}
+
+func snapshot_Syllables(t *testing.T) {
+	var entries []Ci
+	for _, line := range []string{
+		"卡拉OK 卡拉OK [ka3 la1 O K] /karaoke (loanword)/",
+		"中國 中国 [Zhong1 guo2] /China/",
+		"大衛·艾登堡 大卫·艾登堡 [Da4 wei4 · Ai4 deng1 bao3] /David Attenborough/",
+		"綠 绿 [lu:4] /green/",
+		"𠮷 𠮷 [xx5] /unknown/",
+		"平板 平板 [[ping2ban3 {A}pad]] /tablet/",
+	} {
+		ci, err := ParseLine(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, ci)
+	}
+	// built by hand rather than parsed
+	entries = append(entries, Ci{Fantizi: "空", Jiantizi: "空", PinyinRaw: "ZHONG1", Pinyin: []PinyinV2{{Word: []PinyinV1{{Sound: "ZHONG", Tone: T1, Type: Normal}}}, {Word: []PinyinV1{}}}})
+
+	got, err := ReadSnapshot(bytes.NewReader(writeSnapshot(t, entries)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, entries) {
+		for i := range entries {
+			if !reflect.DeepEqual(got[i], entries[i]) {
+				t.Errorf("expected %v\ngot      %v", entries[i], got[i])
+			}
+		}
+	}
+}
+
+func snapshot_Interning(t *testing.T) {
+	ci, err := ParseLine("中 中 [zhong1] /surname Zhong/middle/surname Zhong/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	one := writeSnapshot(t, []Ci{ci})
+	many := writeSnapshot(t, []Ci{ci, ci, ci, ci})
+
+	// each copy adds a record, sense ids, words and syllables but no text
+	perEntry := snapshot_entry_len + 3*4 + 2 + 2 + 4*4
+	if grown := len(many) - len(one); grown > 3*perEntry+8*snapshot_section_count {
+		t.Errorf("expected repeated strings to be shared, snapshot grew by %d bytes", grown)
+	}
+
+	entries, err := ReadSnapshot(bytes.NewReader(many))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries[0].Gloss[0] != entries[3].Gloss[2] {
+		t.Error("expected equal senses")
+	}
+}
+
+func snapshot_Lookup(t *testing.T) {
+	idx := loadSampleIndex(t)
+	s, err := NewSnapshot(writeSnapshot(t, idx.Entries()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ci := range idx.Entries() {
+		for _, w := range []string{ci.Jiantizi, ci.Fantizi} {
+			if got, expected := s.Lookup(w), idx.Lookup(w); !reflect.DeepEqual(got, expected) {
+				t.Errorf("lookup %s: expected %v, got %v", w, headwords(expected), headwords(got))
+			}
+		}
+		for _, q := range []string{tonedKey(ci), tonelessKey(ci), ci.PinyinRaw} {
+			if got, expected := s.LookupPinyin(q), idx.LookupPinyin(q); !reflect.DeepEqual(got, expected) {
+				t.Errorf("pinyin %s: expected %v, got %v", q, headwords(expected), headwords(got))
+			}
+		}
+	}
+
+	for _, q := range []string{"", "不存在"} {
+		if got := s.Lookup(q); len(got) != 0 {
+			t.Errorf("lookup %q: expected nothing, got %v", q, headwords(got))
+		}
+		if got := s.LookupPinyin(q); len(got) != 0 {
+			t.Errorf("pinyin %q: expected nothing, got %v", q, headwords(got))
+		}
+	}
+}
+
+func snapshot_Errors(t *testing.T) {
+	data := writeSnapshot(t, loadSample(t))
+	modified := func(f func(b []byte)) []byte {
+		b := bytes.Clone(data)
+		f(b)
+		return b
+	}
+
+	cases := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{name: "empty", data: nil, expected: ErrSnapshotFormat},
+		{name: "magic", data: []byte("# CC-CEDICT\n"), expected: ErrSnapshotFormat},
+		{name: "version", data: modified(func(b []byte) { binary.LittleEndian.PutUint16(b[8:], SnapshotVersion+1) }), expected: ErrSnapshotVersion},
+		{name: "header", data: modified(func(b []byte) { b[12]++ }), expected: ErrSnapshotChecksum},
+		{name: "section", data: modified(func(b []byte) { b[len(b)-1]++ }), expected: ErrSnapshotChecksum},
+		{name: "truncated", data: data[:len(data)/2], expected: ErrSnapshotFormat},
+	}
+
+	for _, c := range cases {
+		if _, err := NewSnapshot(c.data); !errors.Is(err, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, err)
+		}
+	}
+}
+
+func snapshot_Open(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cedict.snapshot")
+	if err := os.WriteFile(path, writeSnapshot(t, loadSample(t)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := OpenSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := s.LookupPinyin("zhong1guo2")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Jiantizi != "中国" {
+		t.Errorf("unexpected entries %v", headwords(entries))
+	}
+
+	if err := os.WriteFile(path, []byte("not a snapshot"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenSnapshot(path); !errors.Is(err, ErrSnapshotFormat) {
+		t.Errorf("expected ErrSnapshotFormat, got %v", err)
+	}
+}
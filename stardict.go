@@ -0,0 +1,231 @@
+package cccedictparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// StarDictOptions describes the dictionary written by WriteStarDict.
+type StarDictOptions struct {
+	// BookName is shown by dictionary programs, "CC-CEDICT" when empty.
+	BookName    string
+	Author      string
+	Email       string
+	Website     string
+	Description string
+	Date        string
+	// Dictzip writes a compressed .dict.dz instead of .dict.
+	Dictzip bool
+}
+
+// stardictLess orders keys as StarDict expects in .idx and .syn files: ASCII
+// case-insensitive first, then byte-wise.
+func stardictLess(a string, b string) bool {
+	if c := asciiCaseCompare(a, b); c != 0 {
+		return c < 0
+	}
+	return a < b
+}
+
+func asciiCaseCompare(a string, b string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		ca, cb := asciiLower(a[i]), asciiLower(b[i])
+		if ca != cb {
+			return int(ca) - int(cb)
+		}
+	}
+	return len(a) - len(b)
+}
+
+func asciiLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + ('a' - 'A')
+	}
+	return c
+}
+
+// entryHTML renders one entry as a definition block. Dictionary programs
+// supply their own styles, so only class names are set.
+func entryHTML(ci Ci) string {
+	var b strings.Builder
+	b.WriteString(`<div class="entry"><span class="hanzi">`)
+	b.WriteString(html.EscapeString(ci.Jiantizi))
+	if ci.Fantizi != ci.Jiantizi {
+		b.WriteString(" (" + html.EscapeString(ci.Fantizi) + ")")
+	}
+	b.WriteString(`</span> <span class="pinyin">`)
+	b.WriteString(html.EscapeString(ci.DiacriticPinyin()))
+	b.WriteString(`</span> <span class="zhuyin">`)
+	b.WriteString(html.EscapeString(ci.Zhuyin()))
+	b.WriteString(`</span><ol>`)
+	for _, g := range ci.Gloss {
+		b.WriteString("<li>" + html.EscapeString(g) + "</li>")
+	}
+	b.WriteString(`</ol></div>`)
+	return b.String()
+}
+
+// headwordGroups maps every simplified and traditional headword to the
+// entries written under it, in dictionary order.
+func headwordGroups(entries []Ci) map[string][]int {
+	groups := make(map[string][]int)
+	for i, ci := range entries {
+		groups[ci.Jiantizi] = append(groups[ci.Jiantizi], i)
+		if ci.Fantizi != ci.Jiantizi {
+			groups[ci.Fantizi] = append(groups[ci.Fantizi], i)
+		}
+	}
+	return groups
+}
+
+func groupSignature(ids []int) string {
+	var b strings.Builder
+	for _, i := range ids {
+		fmt.Fprintf(&b, "%d,", i)
+	}
+	return b.String()
+}
+
+// WriteStarDict writes entries as a StarDict dictionary into dir: name.ifo,
+// name.idx, name.dict (or name.dict.dz) and name.syn. Simplified and
+// traditional headwords are keys; pinyin with tone marks, with tone numbers
+// and without tones are synonyms pointing at the simplified key. Definitions
+// are HTML.
+func WriteStarDict(dir string, name string, entries []Ci, opts StarDictOptions) error {
+	groups := headwordGroups(entries)
+
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return stardictLess(keys[i], keys[j]) })
+
+	type article struct {
+		offset uint32
+		size   uint32
+	}
+
+	var dict bytes.Buffer
+	var idx bytes.Buffer
+	articles := make(map[string]article)
+	keyIndex := make(map[string]uint32, len(keys))
+
+	for i, k := range keys {
+		ids := groups[k]
+		sig := groupSignature(ids)
+
+		// a traditional key with the same entries as its simplified key
+		// shares the article
+		a, ok := articles[sig]
+		if !ok {
+			blocks := make([]string, 0, len(ids))
+			for _, id := range ids {
+				blocks = append(blocks, entryHTML(entries[id]))
+			}
+			body := strings.Join(blocks, "\n")
+			a = article{offset: uint32(dict.Len()), size: uint32(len(body))}
+			dict.WriteString(body)
+			articles[sig] = a
+		}
+
+		idx.WriteString(k)
+		idx.WriteByte(0)
+		idx.Write(binary.BigEndian.AppendUint32(nil, a.offset))
+		idx.Write(binary.BigEndian.AppendUint32(nil, a.size))
+		keyIndex[k] = uint32(i)
+	}
+
+	type synonym struct {
+		word  string
+		index uint32
+	}
+	seen := make(map[synonym]bool)
+	var syns []synonym
+	for _, ci := range entries {
+		target := keyIndex[ci.Jiantizi]
+		for _, w := range []string{ci.DiacriticPinyin(), ci.PinyinRaw, tonelessKey(ci)} {
+			s := synonym{word: w, index: target}
+			if w == "" || seen[s] || groups[w] != nil {
+				continue
+			}
+			seen[s] = true
+			syns = append(syns, s)
+		}
+	}
+	sort.SliceStable(syns, func(i, j int) bool { return stardictLess(syns[i].word, syns[j].word) })
+
+	var syn bytes.Buffer
+	for _, s := range syns {
+		syn.WriteString(s.word)
+		syn.WriteByte(0)
+		syn.Write(binary.BigEndian.AppendUint32(nil, s.index))
+	}
+
+	base := filepath.Join(dir, name)
+
+	if opts.Dictzip {
+		var dz bytes.Buffer
+		if err := writeDictzip(&dz, dict.Bytes()); err != nil {
+			return err
+		}
+		if err := os.WriteFile(base+".dict.dz", dz.Bytes(), 0o644); err != nil {
+			return err
+		}
+	} else if err := os.WriteFile(base+".dict", dict.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(base+".idx", idx.Bytes(), 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(base+".syn", syn.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	bookName := opts.BookName
+	if bookName == "" {
+		bookName = "CC-CEDICT"
+	}
+
+	f, err := os.Create(base + ".ifo")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "StarDict's dict ifo file\nversion=2.4.2\n")
+	fmt.Fprintf(w, "bookname=%s\n", ifoValue(bookName))
+	fmt.Fprintf(w, "wordcount=%d\n", len(keys))
+	fmt.Fprintf(w, "synwordcount=%d\n", len(syns))
+	fmt.Fprintf(w, "idxfilesize=%d\n", idx.Len())
+	fmt.Fprintf(w, "sametypesequence=h\n")
+	for _, field := range [][2]string{
+		{"author", opts.Author},
+		{"email", opts.Email},
+		{"website", opts.Website},
+		{"description", opts.Description},
+		{"date", opts.Date},
+	} {
+		if field[1] != "" {
+			fmt.Fprintf(w, "%s=%s\n", field[0], ifoValue(field[1]))
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// ifoValue keeps a value on its line; StarDict uses <br> for line breaks.
+func ifoValue(s string) string {
+	return strings.NewReplacer("\r\n", "<br>", "\n", "<br>", "\r", "").Replace(s)
+}
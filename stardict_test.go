@@ -0,0 +1,167 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStarDict(t *testing.T) {
+	tests := []testItem{
+		{Name: "stardict_Ifo", Test: stardict_Ifo},
+		{Name: "stardict_IdxSortedAndResolves", Test: stardict_IdxSortedAndResolves},
+		{Name: "stardict_SynResolves", Test: stardict_SynResolves},
+		{Name: "stardict_Dictzip", Test: stardict_Dictzip},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+type stardictItem struct {
+	word   string
+	offset uint32
+	size   uint32
+}
+
+func readStarDictIdx(t *testing.T, data []byte) []stardictItem {
+	t.Helper()
+	var items []stardictItem
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 || len(data) < end+9 {
+			t.Fatal("truncated idx")
+		}
+		items = append(items, stardictItem{
+			word:   string(data[:end]),
+			offset: binary.BigEndian.Uint32(data[end+1:]),
+			size:   binary.BigEndian.Uint32(data[end+5:]),
+		})
+		data = data[end+9:]
+	}
+	return items
+}
+
+func writeSampleStarDict(t *testing.T, opts StarDictOptions) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := WriteStarDict(dir, "cedict", loadSample(t), opts); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func stardict_Ifo(t *testing.T) {
+	dir := writeSampleStarDict(t, StarDictOptions{Author: "MDBG", Description: "line one\nline two"})
+
+	ifo := string(readFile(t, filepath.Join(dir, "cedict.ifo")))
+	idx := readFile(t, filepath.Join(dir, "cedict.idx"))
+	items := readStarDictIdx(t, idx)
+
+	for _, expected := range []string{
+		"StarDict's dict ifo file\nversion=2.4.2\n",
+		"bookname=CC-CEDICT\n",
+		"wordcount=" + strconv.Itoa(len(items)) + "\n",
+		"idxfilesize=" + strconv.Itoa(len(idx)) + "\n",
+		"sametypesequence=h\n",
+		"author=MDBG\n",
+		"description=line one<br>line two\n",
+	} {
+		if !strings.Contains(ifo, expected) {
+			t.Errorf("ifo does not contain %q:\n%s", expected, ifo)
+		}
+	}
+}
+
+func stardict_IdxSortedAndResolves(t *testing.T) {
+	dir := writeSampleStarDict(t, StarDictOptions{})
+	items := readStarDictIdx(t, readFile(t, filepath.Join(dir, "cedict.idx")))
+	dict := readFile(t, filepath.Join(dir, "cedict.dict"))
+
+	if !sort.SliceIsSorted(items, func(i, j int) bool { return stardictLess(items[i].word, items[j].word) }) {
+		t.Error("idx is not sorted")
+	}
+
+	defs := make(map[string]string)
+	for _, v := range items {
+		defs[v.word] = string(dict[v.offset : v.offset+v.size])
+	}
+
+	if !strings.Contains(defs["中国"], "Zhōng guó") || !strings.Contains(defs["中国"], "<li>China</li>") {
+		t.Errorf("unexpected definition for 中国: %s", defs["中国"])
+	}
+	if defs["中國"] != defs["中国"] {
+		t.Error("traditional key should share the simplified definition")
+	}
+	if strings.Count(defs["中"], `class="entry"`) != 3 {
+		t.Errorf("expected 3 entries under 中: %s", defs["中"])
+	}
+	if !strings.Contains(defs["卡拉OK"], "karaoke (loanword)") {
+		t.Errorf("unexpected definition for 卡拉OK: %s", defs["卡拉OK"])
+	}
+}
+
+func stardict_SynResolves(t *testing.T) {
+	dir := writeSampleStarDict(t, StarDictOptions{})
+	items := readStarDictIdx(t, readFile(t, filepath.Join(dir, "cedict.idx")))
+	syn := readFile(t, filepath.Join(dir, "cedict.syn"))
+
+	targets := make(map[string][]string)
+	for len(syn) > 0 {
+		end := bytes.IndexByte(syn, 0)
+		word := string(syn[:end])
+		i := binary.BigEndian.Uint32(syn[end+1:])
+		targets[word] = append(targets[word], items[i].word)
+		syn = syn[end+5:]
+	}
+
+	for _, w := range []string{"Zhōng guó", "Zhong1 guo2", "zhongguo"} {
+		if len(targets[w]) != 1 || targets[w][0] != "中国" {
+			t.Errorf("synonym %s: expected 中国, got %v", w, targets[w])
+		}
+	}
+}
+
+func stardict_Dictzip(t *testing.T) {
+	plain := readFile(t, filepath.Join(writeSampleStarDict(t, StarDictOptions{}), "cedict.dict"))
+	dir := writeSampleStarDict(t, StarDictOptions{Dictzip: true})
+
+	if _, err := os.Stat(filepath.Join(dir, "cedict.dict")); err == nil {
+		t.Error("expected no plain .dict when writing dictzip")
+	}
+
+	f, err := os.Open(filepath.Join(dir, "cedict.dict.dz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, plain) {
+		t.Error("dictzip content differs from the plain dict")
+	}
+}
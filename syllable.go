@@ -0,0 +1,99 @@
+package cccedictparser
+
+import "strings"
+
+// two letter initials must come before their one letter prefixes
+var pinyin_initials = []string{
+	`zh`, `ch`, `sh`, `b`, `p`, `m`, `f`, `d`, `t`, `n`, `l`, `g`, `k`, `h`,
+	`j`, `q`, `x`, `r`, `z`, `c`, `s`, `y`, `w`,
+}
+
+// splitSyllable splits a lower case pinyin sound into its spelled initial and
+// the remaining letters. Syllables without an initial return "" as initial.
+func splitSyllable(sound string) (string, string) {
+	for _, v := range pinyin_initials {
+		if strings.HasPrefix(sound, v) && len(sound) > len(v) {
+			return v, sound[len(v):]
+		}
+	}
+	return "", sound
+}
+
+var confusable_initials = map[string]string{
+	`zh`: `z`,
+	`ch`: `c`,
+	`sh`: `s`,
+	`n`:  `l`,
+}
+
+var confusable_finals = map[string]string{
+	`ang`:  `an`,
+	`eng`:  `en`,
+	`ing`:  `in`,
+	`iang`: `ian`,
+	`uang`: `uan`,
+}
+
+// confusableSound maps a sound onto a representative shared by the sounds
+// learners commonly confuse: zh/z, ch/c, sh/s, n/l and the -n/-ng finals.
+func confusableSound(sound string) string {
+	initial, rest := splitSyllable(strings.ToLower(sound))
+	if v, ok := confusable_initials[initial]; ok {
+		initial = v
+	}
+	if v, ok := confusable_finals[rest]; ok {
+		rest = v
+	}
+	return initial + rest
+}
+
+// Initial returns the initial consonant of a regular syllable, e.g. "zh" for
+// zhong. y and w are spelling conventions rather than initials, so yi, wu and
+// other syllables without an initial return "".
+func (p PinyinV1) Initial() string {
+	if p.Type != Normal {
+		return ""
+	}
+	initial, _ := splitSyllable(strings.ToLower(p.Sound))
+	if initial == "y" || initial == "w" {
+		return ""
+	}
+	return initial
+}
+
+// Final returns the final of a regular syllable in the spelling it has after
+// a consonant, with ü written as v: "ong" for zhong, "ian" for yan, "ui" for
+// wei and "ve" for jue. It returns "" for letters and punctuation.
+func (p PinyinV1) Final() string {
+	if p.Type != Normal {
+		return ""
+	}
+	initial, rest := splitSyllable(strings.ToLower(p.Sound))
+
+	switch initial {
+	case "y":
+		if strings.HasPrefix(rest, "u") {
+			rest = "v" + rest[1:]
+		} else if !strings.HasPrefix(rest, "i") {
+			rest = "i" + rest
+		}
+	case "w":
+		if rest != "u" {
+			rest = "u" + rest
+		}
+	case "j", "q", "x":
+		if strings.HasPrefix(rest, "u") {
+			rest = "v" + rest[1:]
+		}
+	}
+
+	switch rest {
+	case "iou":
+		return "iu"
+	case "uei":
+		return "ui"
+	case "uen":
+		return "un"
+	}
+	return rest
+}
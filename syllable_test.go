@@ -0,0 +1,60 @@
+package cccedictparser
+
+import "testing"
+
+func TestSyllable(t *testing.T) {
+	tests := []testItem{
+		{Name: "syllable_InitialAndFinal", Test: syllable_InitialAndFinal},
+		{Name: "syllable_NotNormal", Test: syllable_NotNormal},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+type initialFinal struct {
+	Initial string
+	Final   string
+}
+
+func syllable_InitialAndFinal(t *testing.T) {
+	cases := []testCase[initialFinal]{
+		{Sentence: "zhong", Expected: initialFinal{"zh", "ong"}},
+		{Sentence: "Zhong", Expected: initialFinal{"zh", "ong"}},
+		{Sentence: "an", Expected: initialFinal{"", "an"}},
+		{Sentence: "er", Expected: initialFinal{"", "er"}},
+		{Sentence: "yi", Expected: initialFinal{"", "i"}},
+		{Sentence: "yan", Expected: initialFinal{"", "ian"}},
+		{Sentence: "you", Expected: initialFinal{"", "iu"}},
+		{Sentence: "yu", Expected: initialFinal{"", "v"}},
+		{Sentence: "yuan", Expected: initialFinal{"", "van"}},
+		{Sentence: "wu", Expected: initialFinal{"", "u"}},
+		{Sentence: "wei", Expected: initialFinal{"", "ui"}},
+		{Sentence: "wen", Expected: initialFinal{"", "un"}},
+		{Sentence: "jue", Expected: initialFinal{"j", "ve"}},
+		{Sentence: "lv", Expected: initialFinal{"l", "v"}},
+		{Sentence: "shi", Expected: initialFinal{"sh", "i"}},
+	}
+
+	for _, v := range cases {
+		p := PinyinV1{Sound: v.Sentence, Tone: T1, Type: Normal}
+		if p.Initial() != v.Expected.Initial || p.Final() != v.Expected.Final {
+			t.Errorf("%s: expected %s+%s, got %s+%s", v.Sentence, v.Expected.Initial, v.Expected.Final, p.Initial(), p.Final())
+		}
+	}
+}
+
+func syllable_NotNormal(t *testing.T) {
+	cases := []PinyinV1{
+		{Sound: "K", Type: Alphabet},
+		{Sound: "·", Type: Special},
+		{Sound: "xx", Tone: T5, Type: Unknown},
+	}
+
+	for _, v := range cases {
+		if v.Initial() != "" || v.Final() != "" {
+			t.Errorf("expected no initial or final for %s", v.String())
+		}
+	}
+}
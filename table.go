@@ -0,0 +1,242 @@
+package cccedictparser
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Column names a column of a CSV/TSV table. The names are used as headers.
+type Column = string
+
+const (
+	ColumnTraditional     Column = "traditional"
+	ColumnSimplified      Column = "simplified"
+	ColumnPinyin          Column = "pinyin"
+	ColumnPinyinDiacritic Column = "pinyin_diacritic"
+	ColumnZhuyin          Column = "zhuyin"
+	ColumnGloss           Column = "gloss"
+	ColumnFormatVersion   Column = "format"
+)
+
+// DefaultColumns are written when TableOptions.Columns is empty.
+var DefaultColumns = []Column{ColumnTraditional, ColumnSimplified, ColumnPinyin, ColumnGloss}
+
+// TableOptions configures WriteTable and ReadTable. The zero value writes
+// comma separated DefaultColumns with a header row and "/" between senses.
+type TableOptions struct {
+	Columns []Column
+	// Comma is the field delimiter, ',' when zero. Use '\t' for TSV.
+	Comma rune
+	// GlossSeparator joins the senses of an entry, "/" when empty. It must
+	// not occur inside a sense for the table to be read back.
+	GlossSeparator string
+	// NoHeader omits the header row. When reading without a header Columns
+	// gives the column order; with a header the header does.
+	NoHeader bool
+}
+
+func (o TableOptions) columns() []Column {
+	if len(o.Columns) == 0 {
+		return DefaultColumns
+	}
+	return o.Columns
+}
+
+func (o TableOptions) comma() rune {
+	if o.Comma == 0 {
+		return ','
+	}
+	return o.Comma
+}
+
+func (o TableOptions) glossSeparator() string {
+	if o.GlossSeparator == "" {
+		return "/"
+	}
+	return o.GlossSeparator
+}
+
+func columnValue(ci Ci, c Column, glossSeparator string) (string, error) {
+	switch c {
+	case ColumnTraditional:
+		return ci.Fantizi, nil
+	case ColumnSimplified:
+		return ci.Jiantizi, nil
+	case ColumnPinyin:
+		return ci.PinyinRaw, nil
+	case ColumnPinyinDiacritic:
+		return ci.DiacriticPinyin(), nil
+	case ColumnZhuyin:
+		return ci.Zhuyin(), nil
+	case ColumnGloss:
+		return strings.Join(ci.Gloss, glossSeparator), nil
+	case ColumnFormatVersion:
+		return ci.FormatVersion, nil
+	default:
+		return "", fmt.Errorf("unknown column (%s)", c)
+	}
+}
+
+// TableWriter writes entries as CSV or TSV rows.
+type TableWriter struct {
+	w           *csv.Writer
+	opts        TableOptions
+	wroteHeader bool
+}
+
+func NewTableWriter(w io.Writer, opts TableOptions) *TableWriter {
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.comma()
+	return &TableWriter{w: cw, opts: opts}
+}
+
+func (t *TableWriter) Write(ci Ci) error {
+	cols := t.opts.columns()
+
+	if !t.wroteHeader && !t.opts.NoHeader {
+		if err := t.w.Write(cols); err != nil {
+			return err
+		}
+	}
+	t.wroteHeader = true
+
+	row := make([]string, 0, len(cols))
+	for _, c := range cols {
+		v, err := columnValue(ci, c, t.opts.glossSeparator())
+		if err != nil {
+			return err
+		}
+		row = append(row, v)
+	}
+	return t.w.Write(row)
+}
+
+// Flush writes any buffered rows to the underlying writer.
+func (t *TableWriter) Flush() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+// WriteTable writes a whole dictionary as a table.
+func WriteTable(w io.Writer, entries []Ci, opts TableOptions) error {
+	tw := NewTableWriter(w, opts)
+	for _, ci := range entries {
+		if err := tw.Write(ci); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// ReadTable reads a table written by WriteTable, possibly edited since.
+// Each row is rebuilt into a cc-cedict line and parsed again, so the pinyin is
+// validated exactly as in a dictionary file. The numbered pinyin and gloss
+// columns are required, as is one of the headword columns (a missing one is
+// copied from the other); diacritic pinyin and zhuyin columns are ignored.
+// Without a format column, rows are read as V1 and fall back to V2. As with
+// ReadDictionary, rows which fail are returned joined in the error as
+// *LineError values, numbered by the line the row starts on, without stopping
+// the read. An error reading r stops it.
+func ReadTable(r io.Reader, opts TableOptions) ([]Ci, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = opts.comma()
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	cols := opts.columns()
+
+	if !opts.NoHeader {
+		header, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+		cols = header
+	}
+
+	pos := make(map[Column]int)
+	for i, c := range cols {
+		pos[strings.TrimSpace(c)] = i
+	}
+
+	_, hasTrad := pos[ColumnTraditional]
+	_, hasSimp := pos[ColumnSimplified]
+	_, hasPinyin := pos[ColumnPinyin]
+	_, hasGloss := pos[ColumnGloss]
+	if !(hasTrad || hasSimp) || !hasPinyin || !hasGloss {
+		return nil, errors.New("table needs a traditional or simplified column, a pinyin column and a gloss column")
+	}
+
+	lineParser := NewLineParser()
+	var entries []Ci
+	var errs []error
+
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			errs = append(errs, &LineError{Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+		// the line the row starts on, quoted fields may span several
+		lineNo, _ := cr.FieldPos(0)
+
+		field := func(c Column) string {
+			if i, ok := pos[c]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		ci := Ci{
+			Fantizi:       field(ColumnTraditional),
+			Jiantizi:      field(ColumnSimplified),
+			PinyinRaw:     field(ColumnPinyin),
+			Gloss:         strings.Split(field(ColumnGloss), opts.glossSeparator()),
+			FormatVersion: field(ColumnFormatVersion),
+		}
+		if ci.Fantizi == "" {
+			ci.Fantizi = ci.Jiantizi
+		}
+		if ci.Jiantizi == "" {
+			ci.Jiantizi = ci.Fantizi
+		}
+
+		var versions []FormatVersion
+		switch ci.FormatVersion {
+		case "":
+			versions = []FormatVersion{V1, V2}
+		case V1, V2:
+			versions = []FormatVersion{ci.FormatVersion}
+		default:
+			errs = append(errs, &LineError{Line: lineNo, Err: fmt.Errorf("unrecognized format version (%s)", ci.FormatVersion)})
+			continue
+		}
+
+		var parsed Ci
+		for _, v := range versions {
+			ci.FormatVersion = v
+			parsed, err = lineParser.ParseLine(FormatLine(ci))
+			if err == nil {
+				break
+			}
+		}
+
+		if err != nil {
+			errs = append(errs, &LineError{Line: lineNo, Err: err})
+			continue
+		}
+		entries = append(entries, parsed)
+	}
+
+	return entries, errors.Join(errs...)
+}
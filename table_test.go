@@ -0,0 +1,158 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	tests := []testItem{
+		{Name: "table_WriteColumns", Test: table_WriteColumns},
+		{Name: "table_RoundTrip", Test: table_RoundTrip},
+		{Name: "table_ReadEditedSheet", Test: table_ReadEditedSheet},
+		{Name: "table_ReadRequiresColumns", Test: table_ReadRequiresColumns},
+		{Name: "table_ReadMultilineRows", Test: table_ReadMultilineRows},
+		{Name: "table_ReadStopsOnReadError", Test: table_ReadStopsOnReadError},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func table_WriteColumns(t *testing.T) {
+	ci, err := ParseLine("中國 中国 [Zhong1 guo2] /China/Middle Kingdom/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = WriteTable(&buf, []Ci{ci}, TableOptions{
+		Columns:        []Column{ColumnSimplified, ColumnPinyinDiacritic, ColumnZhuyin, ColumnGloss},
+		Comma:          '\t',
+		GlossSeparator: "; ",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "simplified\tpinyin_diacritic\tzhuyin\tgloss\n中国\tZhōng guó\tㄓㄨㄥ ㄍㄨㄛˊ\tChina; Middle Kingdom\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	if err := WriteTable(&buf, []Ci{ci}, TableOptions{Columns: []Column{"hanzi"}}); err == nil {
+		t.Error("expected error for unknown column")
+	}
+}
+
+func table_RoundTrip(t *testing.T) {
+	entries := loadSample(t)
+
+	for _, opts := range []TableOptions{
+		{},
+		{Comma: '\t', NoHeader: true, Columns: []Column{ColumnGloss, ColumnPinyin, ColumnSimplified, ColumnTraditional}},
+		{Columns: []Column{ColumnTraditional, ColumnSimplified, ColumnPinyin, ColumnPinyinDiacritic, ColumnGloss, ColumnFormatVersion}},
+	} {
+		var buf bytes.Buffer
+		if err := WriteTable(&buf, entries, opts); err != nil {
+			t.Fatal(err)
+		}
+
+		back, err := ReadTable(&buf, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(back) != len(entries) {
+			t.Fatalf("expected %d entries, got %d", len(entries), len(back))
+		}
+		for i := range entries {
+			if !ciEq(entries[i], back[i]) {
+				t.Errorf("entry %d mismatch: expected %s, got %s", i, entries[i].String(), back[i].String())
+			}
+		}
+	}
+}
+
+func table_ReadEditedSheet(t *testing.T) {
+	in := "simplified,pinyin,gloss\n" +
+		"海啸,hai3 xiao4,tsunami/tidal wave\n" +
+		"坏,huai9,bad\n" +
+		"皮实,pi2shi5,durable\n"
+
+	entries, err := ReadTable(strings.NewReader(in), TableOptions{})
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Fantizi != "海啸" || len(entries[0].Gloss) != 2 {
+		t.Errorf("unexpected first entry %s", entries[0].String())
+	}
+	if entries[1].FormatVersion != V2 {
+		t.Errorf("expected V2 fallback for pi2shi5, got %s", entries[1].FormatVersion)
+	}
+
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != 3 {
+		t.Errorf("expected a line error on line 3, got %v", err)
+	}
+}
+
+func table_ReadRequiresColumns(t *testing.T) {
+	in := "simplified,pinyin_diacritic,gloss\n海啸,hǎi xiào,tsunami\n"
+
+	if _, err := ReadTable(strings.NewReader(in), TableOptions{}); err == nil {
+		t.Error("expected error without a numbered pinyin column")
+	}
+}
+
+func table_ReadMultilineRows(t *testing.T) {
+	in := "simplified,pinyin,gloss\n" +
+		"海啸,hai3 xiao4,\"tsunami\ntidal wave\"\n" +
+		"坏,huai9,bad\n" +
+		"好,hao3,\"good\" \"fine\"\n"
+
+	entries, err := ReadTable(strings.NewReader(in), TableOptions{})
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != 4 {
+		t.Errorf("expected a line error on line 4, got %v", err)
+	}
+}
+
+type failingReader struct {
+	reads int
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	r.reads++
+	if r.reads == 1 {
+		return copy(p, "simplified,pinyin,gloss\n海啸,hai3 xiao4,tsunami\n"), nil
+	}
+	return 0, errFailingReader
+}
+
+var errFailingReader = errors.New("read failed")
+
+func table_ReadStopsOnReadError(t *testing.T) {
+	r := &failingReader{}
+
+	entries, err := ReadTable(r, TableOptions{})
+
+	if !errors.Is(err, errFailingReader) {
+		t.Errorf("expected the read error, got %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected the entry read before the error, got %d", len(entries))
+	}
+	if r.reads > 3 {
+		t.Errorf("expected the read to stop, got %d reads", r.reads)
+	}
+}
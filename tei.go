@@ -0,0 +1,161 @@
+package cccedictparser
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+)
+
+const tei_namespace = "http://www.tei-c.org/ns/1.0"
+
+// xml_namespace is bound to the xml prefix, for xml:id and xml:lang.
+const xml_namespace = "http://www.w3.org/XML/1998/namespace"
+
+// TEIOptions fills the teiHeader of a TEI Lex-0 dictionary.
+type TEIOptions struct {
+	// Title is "CC-CEDICT" when empty.
+	Title string
+	// Publication describes the publication and license, e.g. "Creative
+	// Commons Attribution-ShareAlike 4.0 International License".
+	Publication string
+	// Source describes where the entries come from, "CC-CEDICT" when empty.
+	Source string
+}
+
+type teiHeader struct {
+	XMLName     xml.Name `xml:"teiHeader"`
+	Title       string   `xml:"fileDesc>titleStmt>title"`
+	Publication string   `xml:"fileDesc>publicationStmt>p"`
+	Source      string   `xml:"fileDesc>sourceDesc>p"`
+}
+
+type teiEntry struct {
+	XMLName xml.Name   `xml:"entry"`
+	ID      string     `xml:"http://www.w3.org/XML/1998/namespace id,attr"`
+	Lang    string     `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Form    teiForm    `xml:"form"`
+	Senses  []teiSense `xml:"sense"`
+}
+
+type teiForm struct {
+	Type  string    `xml:"type,attr"`
+	Orths []teiText `xml:"orth"`
+	Prons []teiPron `xml:"pron"`
+}
+
+type teiText struct {
+	Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	Text string `xml:",chardata"`
+}
+
+type teiPron struct {
+	Notation string `xml:"notation,attr"`
+	Text     string `xml:",chardata"`
+}
+
+type teiSense struct {
+	ID  string  `xml:"http://www.w3.org/XML/1998/namespace id,attr"`
+	N   int     `xml:"n,attr"`
+	Def teiText `xml:"def"`
+}
+
+// teiEntryFor models ci as a Lex-0 entry with the number n: a lemma form with
+// the simplified and traditional orthographies and the pinyin, with tone
+// marks and with tone numbers, then one sense per gloss.
+func teiEntryFor(ci Ci, n int) teiEntry {
+	id := "e" + strconv.Itoa(n)
+	e := teiEntry{
+		ID:   id,
+		Lang: "zh",
+		Form: teiForm{
+			Type:  "lemma",
+			Orths: []teiText{{Lang: "zh-Hans", Text: ci.Jiantizi}},
+			Prons: []teiPron{
+				{Notation: "pinyin", Text: ci.DiacriticPinyin()},
+				{Notation: "pinyin-numbered", Text: ci.PinyinRaw},
+			},
+		},
+	}
+	if ci.Fantizi != ci.Jiantizi {
+		e.Form.Orths = append(e.Form.Orths, teiText{Lang: "zh-Hant", Text: ci.Fantizi})
+	}
+
+	for i, g := range ci.Gloss {
+		e.Senses = append(e.Senses, teiSense{
+			ID:  id + "." + strconv.Itoa(i+1),
+			N:   i + 1,
+			Def: teiText{Lang: "en", Text: g},
+		})
+	}
+	return e
+}
+
+// WriteTEI writes entries as a TEI Lex-0 document. Entries are numbered in
+// order (xml:id "e1", "e2", ..., senses "e1.1", ...) so they can be referred
+// to from other documents as long as the input does not change. A TEI body
+// cannot be empty, so WriteTEI fails without writing when there are no
+// entries.
+func WriteTEI(w io.Writer, entries []Ci, opts TEIOptions) error {
+	if len(entries) == 0 {
+		return errors.New("a TEI document needs at least one entry")
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := io.WriteString(bw, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(bw)
+	enc.Indent("", " ")
+
+	root := xml.StartElement{
+		Name: xml.Name{Local: "TEI"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: tei_namespace}},
+	}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+
+	header := teiHeader{Title: opts.Title, Publication: opts.Publication, Source: opts.Source}
+	if header.Title == "" {
+		header.Title = "CC-CEDICT"
+	}
+	if header.Source == "" {
+		header.Source = "CC-CEDICT"
+	}
+	if header.Publication == "" {
+		header.Publication = "Unpublished"
+	}
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+
+	text := xml.StartElement{Name: xml.Name{Local: "text"}}
+	body := xml.StartElement{Name: xml.Name{Local: "body"}}
+	for _, t := range []xml.Token{text, body} {
+		if err := enc.EncodeToken(t); err != nil {
+			return err
+		}
+	}
+
+	for i, ci := range entries {
+		if err := enc.Encode(teiEntryFor(ci, i+1)); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range []xml.Token{body.End(), text.End(), root.End()} {
+		if err := enc.EncodeToken(t); err != nil {
+			return err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(bw, "\n"); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
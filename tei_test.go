@@ -0,0 +1,91 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+func TestTEI(t *testing.T) {
+	tests := []testItem{
+		{Name: "tei_Entries", Test: tei_Entries},
+		{Name: "tei_Schema", Test: tei_Schema},
+		{Name: "tei_NoEntries", Test: tei_NoEntries},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func tei_Entries(t *testing.T) {
+	entries := loadSample(t)
+
+	var buf bytes.Buffer
+	if err := WriteTEI(&buf, entries, TEIOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		XMLName xml.Name   `xml:"http://www.tei-c.org/ns/1.0 TEI"`
+		Title   string     `xml:"teiHeader>fileDesc>titleStmt>title"`
+		Entries []teiEntry `xml:"text>body>entry"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Title != "CC-CEDICT" {
+		t.Errorf("unexpected title %s", doc.Title)
+	}
+	if len(doc.Entries) != len(entries) {
+		t.Fatalf("expected %d entries, got %d", len(entries), len(doc.Entries))
+	}
+
+	// 中國 中国 [Zhong1 guo2] /China/
+	var found bool
+	for _, e := range doc.Entries {
+		if e.Form.Orths[0].Text != "中国" {
+			continue
+		}
+		found = true
+		if len(e.Form.Orths) != 2 || e.Form.Orths[0].Lang != "zh-Hans" || e.Form.Orths[1].Lang != "zh-Hant" || e.Form.Orths[1].Text != "中國" {
+			t.Errorf("unexpected forms %+v", e.Form.Orths)
+		}
+		if len(e.Form.Prons) != 2 || e.Form.Prons[0].Text != "Zhōng guó" || e.Form.Prons[1].Text != "Zhong1 guo2" {
+			t.Errorf("unexpected pronunciations %+v", e.Form.Prons)
+		}
+		if len(e.Senses) != 1 || e.Senses[0].Def.Text != "China" || e.Senses[0].ID != e.ID+".1" || e.Senses[0].Def.Lang != "en" {
+			t.Errorf("unexpected senses %+v", e.Senses)
+		}
+	}
+	if !found {
+		t.Errorf("entry 中国 not found")
+	}
+
+	seen := make(map[string]bool)
+	for _, e := range doc.Entries {
+		if seen[e.ID] {
+			t.Errorf("duplicate id %s", e.ID)
+		}
+		seen[e.ID] = true
+	}
+}
+
+func tei_Schema(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTEI(&buf, loadSample(t), TEIOptions{Publication: "CC BY-SA 4.0"}); err != nil {
+		t.Fatal(err)
+	}
+	validateXML(t, buf.Bytes(), "testdata/tei_lex0.dtd", "TEI")
+}
+
+func tei_NoEntries(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTEI(&buf, nil, TEIOptions{}); err == nil {
+		t.Error("expected an error without entries")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing written, got %q", buf.String())
+	}
+}
@@ -0,0 +1,139 @@
+package cccedictparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AnyTone matches every tone in a TonePattern or rhyme query.
+const AnyTone Tone = 0xff
+
+// TonePattern is a sequence of tones, one per syllable, where AnyTone
+// matches any tone.
+type TonePattern []Tone
+
+// ParseTonePattern reads a pattern such as "3-3", "4444" or "3 ?".
+// Tones are written 1-5, "?" or "*" stand for any tone and "-", "," or
+// spaces may separate syllables.
+func ParseTonePattern(s string) (TonePattern, error) {
+	tp := make(TonePattern, 0, len(s))
+	for _, r := range s {
+		switch r {
+		case '-', ',', ' ':
+			continue
+		case '?', '*':
+			tp = append(tp, AnyTone)
+		default:
+			tone, err := getTone(r)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tone pattern (%s): %w", s, err)
+			}
+			tp = append(tp, tone)
+		}
+	}
+
+	if len(tp) == 0 {
+		return nil, errors.New("empty tone pattern")
+	}
+
+	return tp, nil
+}
+
+func (tp TonePattern) String() string {
+	var b strings.Builder
+	for i, t := range tp {
+		if i > 0 {
+			b.WriteByte('-')
+		}
+		if t == AnyTone {
+			b.WriteByte('?')
+		} else {
+			b.WriteByte('0' + t)
+		}
+	}
+	return b.String()
+}
+
+// ToneContour returns the tone of every syllable of ci, skipping punctuation.
+func ToneContour(ci Ci) []Tone {
+	syllables := keySyllables(ci)
+	tones := make([]Tone, 0, len(syllables))
+	for _, p := range syllables {
+		tones = append(tones, p.Tone)
+	}
+	return tones
+}
+
+func (tp TonePattern) matchesContour(contour []Tone) bool {
+	if len(tp) != len(contour) {
+		return false
+	}
+	for i, t := range tp {
+		if t != AnyTone && t != contour[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether ci has exactly the syllables and tones of tp.
+func (tp TonePattern) Matches(ci Ci) bool {
+	return tp.matchesContour(ToneContour(ci))
+}
+
+type toneEntry struct {
+	entry   int
+	contour []Tone
+}
+
+func (idx *Index) buildTones() {
+	idx.byContourLen = make(map[int][]toneEntry)
+	idx.byFinal = make(map[string][]int)
+
+	for i, ci := range idx.entries {
+		contour := ToneContour(ci)
+		if len(contour) == 0 {
+			continue
+		}
+		idx.byContourLen[len(contour)] = append(idx.byContourLen[len(contour)], toneEntry{entry: i, contour: contour})
+
+		syllables := keySyllables(ci)
+		if f := syllables[len(syllables)-1].Final(); f != "" {
+			idx.byFinal[f] = append(idx.byFinal[f], i)
+		}
+	}
+}
+
+// ByTonePattern returns the entries whose tone contour matches tp, e.g. every
+// two syllable word read 3-3.
+func (idx *Index) ByTonePattern(tp TonePattern) []Ci {
+	idx.toneOnce.Do(idx.buildTones)
+
+	out := make([]Ci, 0)
+	for _, v := range idx.byContourLen[len(tp)] {
+		if tp.matchesContour(v.contour) {
+			out = append(out, idx.entries[v.entry])
+		}
+	}
+	return out
+}
+
+// ByRhyme returns the entries whose last syllable has the given final (as
+// returned by PinyinV1.Final; "ü" and "u:" are accepted for "v") and tone.
+// Pass AnyTone to match the final in any tone.
+func (idx *Index) ByRhyme(final string, tone Tone) []Ci {
+	idx.toneOnce.Do(idx.buildTones)
+
+	final = strings.NewReplacer("u:", "v", "ü", "v").Replace(strings.ToLower(final))
+
+	out := make([]Ci, 0)
+	for _, i := range idx.byFinal[final] {
+		ci := idx.entries[i]
+		syllables := keySyllables(ci)
+		if tone == AnyTone || syllables[len(syllables)-1].Tone == tone {
+			out = append(out, ci)
+		}
+	}
+	return out
+}
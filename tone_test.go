@@ -0,0 +1,83 @@
+package cccedictparser
+
+import "testing"
+
+func TestTone(t *testing.T) {
+	tests := []testItem{
+		{Name: "tonePattern_Parse", Test: tonePattern_Parse},
+		{Name: "tonePattern_ParseError", Test: tonePattern_ParseError},
+		{Name: "index_ByTonePattern", Test: index_ByTonePattern},
+		{Name: "index_ByRhyme", Test: index_ByRhyme},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func tonePattern_Parse(t *testing.T) {
+	cases := []testCase[string]{
+		{Sentence: "3-3", Expected: "3-3"},
+		{Sentence: "4444", Expected: "4-4-4-4"},
+		{Sentence: "3 ?", Expected: "3-?"},
+		{Sentence: "*,5", Expected: "?-5"},
+	}
+
+	for _, v := range cases {
+		tp, err := ParseTonePattern(v.Sentence)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %s", v.Sentence, err.Error())
+			continue
+		}
+		if tp.String() != v.Expected {
+			t.Errorf("expected %s, got %s", v.Expected, tp.String())
+		}
+	}
+}
+
+func tonePattern_ParseError(t *testing.T) {
+	for _, v := range []string{"", "3-6", "a"} {
+		if _, err := ParseTonePattern(v); err == nil {
+			t.Errorf("expected error for pattern %q", v)
+		}
+	}
+}
+
+func index_ByTonePattern(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	cases := []testCase[string]{
+		{Sentence: "3-3", Expected: "你好,水果,美好,老虎,老鼠,雨伞,领导"},
+		{Sentence: "4-4-4-4", Expected: "见利忘义"},
+		{Sentence: "?-5", Expected: "你们,学生,我们,皮实"},
+		{Sentence: "1-1-1-4", Expected: "一心一意"},
+		{Sentence: "1-1-1-1", Expected: ""},
+	}
+
+	for _, v := range cases {
+		tp, _ := ParseTonePattern(v.Sentence)
+		if got := sortedHeadwords(idx.ByTonePattern(tp)); got != v.Expected {
+			t.Errorf("pattern %s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
+
+func index_ByRhyme(t *testing.T) {
+	idx := loadSampleIndex(t)
+
+	cases := []testCase[string]{
+		{Sentence: "ao", Expected: "你好,号,好,好,美好,老,脑,领导"},
+		{Sentence: "ian", Expected: "先"},
+		{Sentence: "ü", Expected: "女,绿"},
+	}
+
+	for _, v := range cases {
+		if got := sortedHeadwords(idx.ByRhyme(v.Sentence, AnyTone)); got != v.Expected {
+			t.Errorf("rhyme %s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+
+	if got := sortedHeadwords(idx.ByRhyme("ao", T4)); got != "号,好" {
+		t.Errorf("rhyme ao4: expected 号,好 got %s", got)
+	}
+}
@@ -0,0 +1,80 @@
+package cccedictparser
+
+import (
+	"html"
+	"strings"
+)
+
+// tone_colors are Pleco's default tone colors, indexed by tone
+var tone_colors = [...]string{
+	T1: "#e30000",
+	T2: "#02b31c",
+	T3: "#1510f0",
+	T4: "#8900bf",
+	T5: "#777777",
+}
+
+// ToneColor returns the color of a tone as a CSS hex color, "" for None and
+// unknown tones.
+func ToneColor(t Tone) string {
+	if int(t) >= len(tone_colors) {
+		return ""
+	}
+	return tone_colors[t]
+}
+
+// writeToneSpan writes s escaped, in a span colored by the tone of p. Letters,
+// punctuation and syllables without tone are written without span.
+func writeToneSpan(b *strings.Builder, p PinyinV1, s string) {
+	color := ToneColor(p.Tone)
+	if p.Type != Normal || color == "" {
+		b.WriteString(html.EscapeString(s))
+		return
+	}
+	b.WriteString(`<span class="tone`)
+	b.WriteByte('0' + p.Tone)
+	b.WriteString(`" style="color:` + color + `">`)
+	b.WriteString(html.EscapeString(s))
+	b.WriteString(`</span>`)
+}
+
+// ToneHTML returns the pinyin with tone marks, each syllable in a span
+// colored by its tone, e.g. <span class="tone1" style="color:#e30000">zhōng</span>.
+// Words are separated by spaces as in DiacriticPinyin.
+func (ci Ci) ToneHTML() string {
+	var b strings.Builder
+	for i, w := range ci.Pinyin {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		for j, p := range w.Word {
+			if j > 0 && p.Type == Normal && strings.ContainsRune("aeoAEO", firstRune(p.Sound)) {
+				b.WriteByte('\'')
+			}
+			writeToneSpan(&b, p, p.Diacritic())
+		}
+	}
+	return b.String()
+}
+
+// HanziToneHTML returns hanzi, a headword of ci, with every character in a
+// span colored by the tone of its syllable. When the characters do not line
+// up with the syllables, e.g. for headwords with Latin letters, hanzi is
+// returned escaped and uncolored.
+func (ci Ci) HanziToneHTML(hanzi string) string {
+	var syllables []PinyinV1
+	for _, w := range ci.Pinyin {
+		syllables = append(syllables, w.Word...)
+	}
+
+	runes := []rune(hanzi)
+	if len(runes) != len(syllables) {
+		return html.EscapeString(hanzi)
+	}
+
+	var b strings.Builder
+	for i, r := range runes {
+		writeToneSpan(&b, syllables[i], string(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package cccedictparser
+
+import "testing"
+
+func TestToneColor(t *testing.T) {
+	tests := []testItem{
+		{Name: "toneColor_Pinyin", Test: toneColor_Pinyin},
+		{Name: "toneColor_Hanzi", Test: toneColor_Hanzi},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func toneColor_Pinyin(t *testing.T) {
+	cases := []testCase[string]{
+		{Sentence: "你們 你们 [ni3 men5] /you (plural)/", Expected: `<span class="tone3" style="color:#1510f0">nǐ</span> <span class="tone5" style="color:#777777">men</span>`},
+		{Sentence: "西安 西安 [[Xi1an1]] /Xi'an/", Expected: `<span class="tone1" style="color:#e30000">Xī</span>'<span class="tone1" style="color:#e30000">ān</span>`},
+		{Sentence: "卡拉OK 卡拉OK [ka3 la1 O K] /karaoke/", Expected: `<span class="tone3" style="color:#1510f0">kǎ</span> <span class="tone1" style="color:#e30000">lā</span> O K`},
+	}
+
+	for _, v := range cases {
+		ci, err := ParseLine(v.Sentence)
+		if err != nil {
+			t.Errorf("error: %s. Line %s", err.Error(), v.Sentence)
+			continue
+		}
+		if got := ci.ToneHTML(); got != v.Expected {
+			t.Errorf("%s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
+
+func toneColor_Hanzi(t *testing.T) {
+	cases := []testCase[string]{
+		{Sentence: "中國 中国 [Zhong1 guo2] /China/", Expected: `<span class="tone1" style="color:#e30000">中</span><span class="tone2" style="color:#02b31c">國</span>`},
+		{Sentence: "卡拉OK 卡拉OK [ka3 la1 O K] /karaoke/", Expected: `<span class="tone3" style="color:#1510f0">卡</span><span class="tone1" style="color:#e30000">拉</span>OK`},
+		{Sentence: "A&B A&B [A B] /test/", Expected: `A&amp;B`},
+	}
+
+	for _, v := range cases {
+		ci, err := ParseLine(v.Sentence)
+		if err != nil {
+			t.Errorf("error: %s. Line %s", err.Error(), v.Sentence)
+			continue
+		}
+		if got := ci.HanziToneHTML(ci.Fantizi); got != v.Expected {
+			t.Errorf("%s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+
+	if ToneColor(None) != "" || ToneColor(9) != "" || ToneColor(T4) != "#8900bf" {
+		t.Errorf("unexpected tone colors")
+	}
+}
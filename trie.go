@@ -0,0 +1,330 @@
+package cccedictparser
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const trie_magic = "CCTRIE"
+const trie_version = 2
+
+// Trie is a compact byte-wise prefix tree over the simplified and traditional
+// headwords and toneless pinyin of a dictionary, for autocomplete.
+// It stores entry positions only, so it is tied to the []Ci it was built from.
+type Trie struct {
+	entries []Ci
+
+	// node i owns labels/targets[edgeStart[i]:edgeStart[i+1]], sorted by
+	// label, and values[valueStart[i]:valueStart[i+1]]
+	edgeStart  []uint32
+	valueStart []uint32
+	labels     []byte
+	targets    []uint32
+	values     []uint32
+}
+
+type trieBuildNode struct {
+	children map[byte]*trieBuildNode
+	values   []uint32
+}
+
+// normalizeTrieKey lower-cases pinyin keys and queries and drops the
+// characters which do not take part in them. Only a digit 1-5 following a
+// letter is a tone number, so "3c" is kept as it is.
+func normalizeTrieKey(s string) string {
+	s = normalizePinyinQuery(s)
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c >= '1' && c <= '5' && i > 0 && s[i-1] >= 'a' && s[i-1] <= 'z' {
+			continue
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+// normalizeHeadwordKey lower-cases headword keys and queries, so "3C" is
+// found from "3c" but not from "c".
+func normalizeHeadwordKey(s string) string {
+	return strings.ToLower(s)
+}
+
+// Headword and pinyin keys start with a byte naming their kind, so a query is
+// matched against headwords and pinyin with its own normalization for each.
+// Headword keys sort first.
+const (
+	trie_headword_key = 0
+	trie_pinyin_key   = 1
+)
+
+// NewTrie builds a trie over entries. The slice is retained, not copied.
+func NewTrie(entries []Ci) *Trie {
+	root := &trieBuildNode{}
+
+	insert := func(kind byte, key string, entry uint32) {
+		if key == "" {
+			return
+		}
+		key = string(kind) + key
+		node := root
+		for i := 0; i < len(key); i++ {
+			if node.children == nil {
+				node.children = make(map[byte]*trieBuildNode)
+			}
+			child, ok := node.children[key[i]]
+			if !ok {
+				child = &trieBuildNode{}
+				node.children[key[i]] = child
+			}
+			node = child
+		}
+		if n := len(node.values); n == 0 || node.values[n-1] != entry {
+			node.values = append(node.values, entry)
+		}
+	}
+
+	for i, ci := range entries {
+		insert(trie_headword_key, normalizeHeadwordKey(ci.Jiantizi), uint32(i))
+		insert(trie_headword_key, normalizeHeadwordKey(ci.Fantizi), uint32(i))
+		insert(trie_pinyin_key, tonelessKey(ci), uint32(i))
+	}
+
+	t := &Trie{entries: entries}
+
+	// flatten breadth first so node ids are assigned before their edges
+	// are written
+	queue := []*trieBuildNode{root}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		t.edgeStart = append(t.edgeStart, uint32(len(t.labels)))
+		t.valueStart = append(t.valueStart, uint32(len(t.values)))
+		t.values = append(t.values, node.values...)
+
+		labels := make([]byte, 0, len(node.children))
+		for l := range node.children {
+			labels = append(labels, l)
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i] < labels[j] })
+
+		for _, l := range labels {
+			t.labels = append(t.labels, l)
+			// the child's id is its position in the flattened order
+			t.targets = append(t.targets, uint32(len(t.edgeStart)+len(queue)))
+			queue = append(queue, node.children[l])
+		}
+	}
+	t.edgeStart = append(t.edgeStart, uint32(len(t.labels)))
+	t.valueStart = append(t.valueStart, uint32(len(t.values)))
+
+	return t
+}
+
+// Trie returns a trie over the indexed entries, built on first use and shared
+// by later calls.
+func (idx *Index) Trie() *Trie {
+	idx.trieOnce.Do(func() { idx.trie = NewTrie(idx.entries) })
+	return idx.trie
+}
+
+func (t *Trie) child(node uint32, label byte) (uint32, bool) {
+	lo, hi := int(t.edgeStart[node]), int(t.edgeStart[node+1])
+	i := lo + sort.Search(hi-lo, func(i int) bool { return t.labels[lo+i] >= label })
+	if i < hi && t.labels[i] == label {
+		return t.targets[i], true
+	}
+	return 0, false
+}
+
+// Prefix returns up to limit entries with a simplified or traditional
+// headword or a toneless pinyin key starting with prefix. Shorter keys come
+// first, so an exact match precedes its extensions; at equal length headword
+// matches come before pinyin ones. Headwords are matched ignoring case,
+// pinyin ignoring case, spaces and tone numbers. A limit <= 0 returns every
+// match.
+func (t *Trie) Prefix(prefix string, limit int) []Ci {
+	// the nodes of each kind of key at the current depth, in key order
+	var frontiers [2][]uint32
+	for kind, key := range [2]string{
+		trie_headword_key: normalizeHeadwordKey(prefix),
+		trie_pinyin_key:   normalizeTrieKey(prefix),
+	} {
+		if node, ok := t.find(byte(kind), key); ok {
+			frontiers[kind] = []uint32{node}
+		}
+	}
+	// depths of the frontiers, counted in key bytes
+	depths := [2]int{len(normalizeHeadwordKey(prefix)), len(normalizeTrieKey(prefix))}
+
+	out := make([]Ci, 0)
+	seen := make(map[uint32]bool)
+
+	// breadth first over both kinds at once, so results are ordered by key
+	// length
+	for depth := min(depths[0], depths[1]); len(frontiers[0]) > 0 || len(frontiers[1]) > 0; depth++ {
+		for kind := range frontiers {
+			if depths[kind] != depth {
+				continue
+			}
+
+			var next []uint32
+			for _, n := range frontiers[kind] {
+				for _, v := range t.values[t.valueStart[n]:t.valueStart[n+1]] {
+					if seen[v] {
+						continue
+					}
+					seen[v] = true
+					out = append(out, t.entries[v])
+					if limit > 0 && len(out) == limit {
+						return out
+					}
+				}
+				next = append(next, t.targets[t.edgeStart[n]:t.edgeStart[n+1]]...)
+			}
+			frontiers[kind] = next
+			depths[kind]++
+		}
+	}
+
+	return out
+}
+
+// find returns the node of the key of the given kind, if any.
+func (t *Trie) find(kind byte, key string) (uint32, bool) {
+	if key == "" {
+		return 0, false
+	}
+	node, ok := t.child(0, kind)
+	for i := 0; ok && i < len(key); i++ {
+		node, ok = t.child(node, key[i])
+	}
+	return node, ok
+}
+
+// WriteTo serializes the trie. The entries are not written; ReadTrie must be
+// given the same entries in the same order.
+func (t *Trie) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+
+	header := []uint32{
+		uint32(len(t.entries)),
+		uint32(len(t.edgeStart)),
+		uint32(len(t.labels)),
+		uint32(len(t.values)),
+	}
+
+	cw.Write([]byte(trie_magic))
+	cw.Write([]byte{trie_version})
+	for _, section := range [][]uint32{header, t.edgeStart, t.valueStart} {
+		binary.Write(cw, binary.LittleEndian, section)
+	}
+	cw.Write(t.labels)
+	binary.Write(cw, binary.LittleEndian, t.targets)
+	binary.Write(cw, binary.LittleEndian, t.values)
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, bw.Flush()
+}
+
+// ReadTrie reads a trie written by WriteTo. entries must be the dictionary
+// the trie was built from.
+func ReadTrie(r io.Reader, entries []Ci) (*Trie, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(trie_magic)+1)
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, err
+	}
+	if string(magic[:len(trie_magic)]) != trie_magic {
+		return nil, errors.New("not a trie file")
+	}
+	if magic[len(trie_magic)] != trie_version {
+		return nil, fmt.Errorf("unsupported trie version %d", magic[len(trie_magic)])
+	}
+
+	header := make([]uint32, 4)
+	if err := binary.Read(br, binary.LittleEndian, header); err != nil {
+		return nil, err
+	}
+	if int(header[0]) != len(entries) {
+		return nil, fmt.Errorf("trie was built from %d entries, got %d", header[0], len(entries))
+	}
+
+	// check the counts against entries before allocating: a tree has one
+	// node more than edges, every entry has at most three keys and every key
+	// byte is at most one edge
+	keyBytes := 0
+	for _, ci := range entries {
+		keyBytes += 3 + len(normalizeHeadwordKey(ci.Jiantizi)) + len(normalizeHeadwordKey(ci.Fantizi)) + len(tonelessKey(ci))
+	}
+	if uint64(header[1]) != uint64(header[2])+2 || uint64(header[2]) > uint64(keyBytes) || uint64(header[3]) > 3*uint64(len(entries)) {
+		return nil, errors.New("corrupt trie")
+	}
+
+	t := &Trie{
+		entries:    entries,
+		edgeStart:  make([]uint32, header[1]),
+		valueStart: make([]uint32, header[1]),
+		labels:     make([]byte, header[2]),
+		targets:    make([]uint32, header[2]),
+		values:     make([]uint32, header[3]),
+	}
+
+	for _, section := range []any{t.edgeStart, t.valueStart, t.labels, t.targets, t.values} {
+		if err := binary.Read(br, binary.LittleEndian, section); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(t.edgeStart) < 2 {
+		return nil, errors.New("corrupt trie")
+	}
+	nodes := uint32(len(t.edgeStart) - 1)
+	if t.edgeStart[nodes] != header[2] || t.valueStart[nodes] != header[3] {
+		return nil, errors.New("corrupt trie")
+	}
+	for i := uint32(0); i < nodes; i++ {
+		if t.edgeStart[i] > t.edgeStart[i+1] || t.valueStart[i] > t.valueStart[i+1] {
+			return nil, errors.New("corrupt trie")
+		}
+	}
+	for _, v := range t.targets {
+		if v >= nodes {
+			return nil, errors.New("corrupt trie")
+		}
+	}
+	for _, v := range t.values {
+		if int(v) >= len(entries) {
+			return nil, errors.New("corrupt trie")
+		}
+	}
+
+	return t, nil
+}
+
+// countingWriter remembers the first error and the number of bytes written,
+// so a sequence of writes can be checked once.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
@@ -0,0 +1,147 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestTrie(t *testing.T) {
+	tests := []testItem{
+		{Name: "trie_Prefix", Test: trie_Prefix},
+		{Name: "trie_PrefixLimit", Test: trie_PrefixLimit},
+		{Name: "trie_PrefixHeadwordDigits", Test: trie_PrefixHeadwordDigits},
+		{Name: "trie_RoundTrip", Test: trie_RoundTrip},
+		{Name: "trie_ReadRejectsOtherDictionary", Test: trie_ReadRejectsOtherDictionary},
+		{Name: "trie_ReadRejectsLargeCounts", Test: trie_ReadRejectsLargeCounts},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func trie_Prefix(t *testing.T) {
+	trie := NewTrie(loadSample(t))
+
+	cases := []testCase[string]{
+		{Sentence: "大学", Expected: "大学,大学生"},
+		{Sentence: "大學", Expected: "大学,大学生"},
+		{Sentence: "zhongg", Expected: "中国,中国人"},
+		{Sentence: "Zhong guo", Expected: "中国,中国人"},
+		{Sentence: "zhong1", Expected: "中,中,中,中国,中国人"},
+		{Sentence: "卡拉ok", Expected: "卡拉OK"},
+		{Sentence: "xyz", Expected: ""},
+		{Sentence: "", Expected: ""},
+	}
+
+	for _, v := range cases {
+		if got := strings.Join(headwords(trie.Prefix(v.Sentence, 0)), ","); got != v.Expected {
+			t.Errorf("prefix %s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
+
+func trie_PrefixLimit(t *testing.T) {
+	trie := NewTrie(loadSample(t))
+
+	got := trie.Prefix("da", 2)
+	if len(got) != 2 || got[0].Jiantizi != "大" {
+		t.Errorf("expected 2 results starting with 大, got %v", headwords(got))
+	}
+}
+
+func trie_PrefixHeadwordDigits(t *testing.T) {
+	entries, err := ReadDictionary(strings.NewReader("3C 3C [san1 C] /computers, communications and consumer electronics/\n" +
+		"1 1 [yi1] /one/\n" +
+		"詞 词 [ci2] /word/\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trie := NewTrie(entries)
+
+	// tone numbers are only dropped from pinyin, not from headwords
+	cases := []testCase[string]{
+		{Sentence: "c", Expected: "词"},
+		{Sentence: "3c", Expected: "3C"},
+		{Sentence: "3C", Expected: "3C"},
+		{Sentence: "1", Expected: "1"},
+		{Sentence: "san1", Expected: "3C"},
+		{Sentence: "ci2", Expected: "词"},
+	}
+
+	for _, v := range cases {
+		if got := strings.Join(headwords(trie.Prefix(v.Sentence, 0)), ","); got != v.Expected {
+			t.Errorf("prefix %s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
+
+func trie_RoundTrip(t *testing.T) {
+	entries := loadSample(t)
+	trie := NewTrie(entries)
+
+	var buf bytes.Buffer
+	n, err := trie.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo reported %d bytes, wrote %d", n, buf.Len())
+	}
+
+	read, err := ReadTrie(&buf, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, q := range []string{"xue", "老", "ni", "皮"} {
+		a := strings.Join(headwords(trie.Prefix(q, 0)), ",")
+		b := strings.Join(headwords(read.Prefix(q, 0)), ",")
+		if a != b {
+			t.Errorf("prefix %s differs after round trip: %s vs %s", q, a, b)
+		}
+	}
+}
+
+func trie_ReadRejectsOtherDictionary(t *testing.T) {
+	entries := loadSample(t)
+
+	var buf bytes.Buffer
+	if _, err := NewTrie(entries).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadTrie(bytes.NewReader(buf.Bytes()), entries[1:]); err == nil {
+		t.Error("expected error reading trie against a different dictionary")
+	}
+
+	if _, err := ReadTrie(strings.NewReader("not a trie at all"), entries); err == nil {
+		t.Error("expected error reading garbage")
+	}
+}
+
+func trie_ReadRejectsLargeCounts(t *testing.T) {
+	entries := loadSample(t)
+
+	var buf bytes.Buffer
+	if _, err := NewTrie(entries).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// the node, edge and value counts follow the entry count in the header
+	header := len(trie_magic) + 1 + 4
+	for i := 0; i < 3; i++ {
+		corrupt := bytes.Clone(data)
+		binary.LittleEndian.PutUint32(corrupt[header+4*i:], 0xffffffff)
+		if _, err := ReadTrie(bytes.NewReader(corrupt), entries); err == nil {
+			t.Errorf("expected error with count %d set to 0xffffffff", i)
+		}
+	}
+
+	if _, err := ReadTrie(bytes.NewReader(data[:len(data)/2]), entries); err == nil {
+		t.Error("expected error reading a truncated trie")
+	}
+}
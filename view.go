@@ -0,0 +1,226 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"unsafe"
+)
+
+// Span is a byte range [Start, End) of a dictionary file.
+type Span struct {
+	Start uint32
+	End   uint32
+}
+
+// Len returns the length of s in bytes.
+func (s Span) Len() int {
+	return int(s.End - s.Start)
+}
+
+// Bytes returns the bytes of s in src. They must not be modified.
+func (s Span) Bytes(src []byte) []byte {
+	return src[s.Start:s.End]
+}
+
+// CiView is an entry of a dictionary file kept as offsets into the file
+// rather than as strings, see Views.
+type CiView struct {
+	Fantizi   Span
+	Jiantizi  Span
+	PinyinRaw Span
+	// Gloss runs from after the first "/" to the last one, senses are
+	// separated by "/".
+	Gloss         Span
+	FormatVersion FormatVersion
+}
+
+// Ci materializes v, copying its text out of src.
+func (v CiView) Ci(src []byte) Ci {
+	line := string(src[v.Fantizi.Start : v.Gloss.End+1])
+	base := int(v.Fantizi.Start)
+	sec := lineSections{
+		fantizi:    v.Fantizi.offsets(base),
+		jiantizi:   v.Jiantizi.offsets(base),
+		pinyin:     v.PinyinRaw.offsets(base),
+		gloss:      v.Gloss.offsets(base),
+		glossCount: bytes.Count(v.Gloss.Bytes(src), []byte("/")) + 1,
+		version:    v.FormatVersion,
+	}
+
+	// the line was checked by NewViews
+	ci, _ := parseSections(pinyin_syllables, line, sec)
+	return ci
+}
+
+func (s Span) offsets(base int) [2]int {
+	return [2]int{int(s.Start) - base, int(s.End) - base}
+}
+
+// Views is a dictionary file read in place: entries are CiView offsets into
+// the file bytes and are materialized as Ci on demand. Lookups binary search
+// the headwords in the file, so nothing of the text is copied. With OpenViews
+// the file is memory mapped.
+type Views struct {
+	src           []byte
+	views         []CiView
+	bySimplified  []int32
+	byTraditional []int32
+	close         func() error
+}
+
+// NewViews indexes the dictionary file src, which is retained and must not be
+// modified. Every line is checked as ReadDictionary would and lines which fail
+// are returned joined in the error as *LineError values, without stopping the
+// read. Files must be under 4GiB.
+func NewViews(src []byte) (*Views, error) {
+	if uint64(len(src)) > 1<<32-1 {
+		return nil, errors.New("dictionary file too large for views")
+	}
+
+	vs := &Views{src: src}
+	var errs []error
+	lineNo := 0
+
+	for start := 0; start < len(src); {
+		end := bytes.IndexByte(src[start:], '\n')
+		next := start + end + 1
+		if end < 0 {
+			end = len(src) - start
+			next = len(src)
+		}
+		lineNo++
+
+		b := bytes.TrimSuffix(src[start:start+end], []byte("\r"))
+		lineStart := start
+		start = next
+
+		if len(b) == 0 {
+			continue
+		}
+		// parsed in place: the Ci built to check the line is dropped
+		line := unsafe.String(&b[0], len(b))
+		if isSkippableLine(line) {
+			continue
+		}
+
+		sec, err := scanLine(line)
+		if err == nil {
+			_, err = parseSections(pinyin_syllables, line, sec)
+		}
+		if err != nil {
+			errs = append(errs, &LineError{Line: lineNo, Err: err})
+			continue
+		}
+
+		span := func(o [2]int) Span {
+			return Span{Start: uint32(lineStart + o[0]), End: uint32(lineStart + o[1])}
+		}
+		vs.views = append(vs.views, CiView{
+			Fantizi:       span(sec.fantizi),
+			Jiantizi:      span(sec.jiantizi),
+			PinyinRaw:     span(sec.pinyin),
+			Gloss:         span(sec.gloss),
+			FormatVersion: sec.version,
+		})
+	}
+
+	vs.bySimplified = vs.sortedBy(func(v CiView) Span { return v.Jiantizi })
+	vs.byTraditional = vs.sortedBy(func(v CiView) Span { return v.Fantizi })
+
+	return vs, errors.Join(errs...)
+}
+
+func (vs *Views) sortedBy(key func(CiView) Span) []int32 {
+	order := make([]int32, len(vs.views))
+	for i := range order {
+		order[i] = int32(i)
+	}
+	slices.SortStableFunc(order, func(a, b int32) int {
+		return bytes.Compare(key(vs.views[a]).Bytes(vs.src), key(vs.views[b]).Bytes(vs.src))
+	})
+	return order
+}
+
+// Len returns the number of entries.
+func (vs *Views) Len() int {
+	return len(vs.views)
+}
+
+// View returns the i-th entry, in file order.
+func (vs *Views) View(i int) CiView {
+	return vs.views[i]
+}
+
+// Ci materializes the i-th entry.
+func (vs *Views) Ci(i int) Ci {
+	return vs.views[i].Ci(vs.src)
+}
+
+// Source returns the file bytes the views point into. They must not be
+// modified.
+func (vs *Views) Source() []byte {
+	return vs.src
+}
+
+// find returns the entries whose key is word, in file order.
+func (vs *Views) find(order []int32, key func(CiView) Span, word string) []int32 {
+	w := []byte(word)
+	cmp := func(i int32, w []byte) int {
+		return bytes.Compare(key(vs.views[i]).Bytes(vs.src), w)
+	}
+	lo, _ := slices.BinarySearchFunc(order, w, cmp)
+	hi := lo
+	for hi < len(order) && cmp(order[hi], w) == 0 {
+		hi++
+	}
+	return order[lo:hi]
+}
+
+// Lookup returns the entries whose simplified or traditional headword is
+// word, like Index.Lookup.
+func (vs *Views) Lookup(word string) []Ci {
+	seen := make(map[int32]bool)
+	out := make([]Ci, 0)
+	for _, ids := range [][]int32{
+		vs.find(vs.bySimplified, func(v CiView) Span { return v.Jiantizi }, word),
+		vs.find(vs.byTraditional, func(v CiView) Span { return v.Fantizi }, word),
+	} {
+		for _, i := range ids {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			out = append(out, vs.Ci(int(i)))
+		}
+	}
+	return out
+}
+
+// OpenViews memory maps the dictionary file at path, where the platform
+// allows it, and indexes it with NewViews. Close releases the mapping.
+func OpenViews(path string) (*Views, error) {
+	src, closeFn, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	vs, err := NewViews(src)
+	if vs == nil {
+		closeFn()
+		return nil, err
+	}
+	vs.close = closeFn
+	return vs, err
+}
+
+// Close releases the file of OpenViews. The views and the bytes returned by
+// Source must not be used afterwards; materialized Ci values stay valid.
+func (vs *Views) Close() error {
+	if vs.close == nil {
+		return nil
+	}
+	err := vs.close()
+	vs.close = nil
+	return err
+}
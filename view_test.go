@@ -0,0 +1,133 @@
+package cccedictparser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestViews(t *testing.T) {
+	tests := []testItem{
+		{Name: "views_MatchReadDictionary", Test: views_MatchReadDictionary},
+		{Name: "views_Spans", Test: views_Spans},
+		{Name: "views_LineErrors", Test: views_LineErrors},
+		{Name: "views_Lookup", Test: views_Lookup},
+		{Name: "views_Open", Test: views_Open},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func views_MatchReadDictionary(t *testing.T) {
+	vs, err := NewViews(readFile(t, sampleDictionary))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := loadSample(t)
+	if vs.Len() != len(expected) {
+		t.Fatalf("expected %d views, got %d", len(expected), vs.Len())
+	}
+	for i, ci := range expected {
+		if got := vs.Ci(i); !reflect.DeepEqual(got, ci) {
+			t.Errorf("view %d:\nexpected %v\ngot      %v", i, ci, got)
+		}
+	}
+}
+
+func views_Spans(t *testing.T) {
+	src := []byte("# comment\r\n中國 中国 [Zhong1 guo2] /China/Middle Kingdom/ trailing\r\n\n")
+	vs, err := NewViews(src)
+	if err != nil || vs.Len() != 1 {
+		t.Fatalf("expected one view, got %d (%v)", vs.Len(), err)
+	}
+
+	v := vs.View(0)
+	cases := []testCase[Span]{
+		{Sentence: "中國", Expected: v.Fantizi},
+		{Sentence: "中国", Expected: v.Jiantizi},
+		{Sentence: "Zhong1 guo2", Expected: v.PinyinRaw},
+		{Sentence: "China/Middle Kingdom", Expected: v.Gloss},
+	}
+	for _, c := range cases {
+		if got := string(c.Expected.Bytes(vs.Source())); got != c.Sentence {
+			t.Errorf("expected %s, got %s", c.Sentence, got)
+		}
+	}
+
+	ci := v.Ci(src)
+	if FormatLine(ci) != "中國 中国 [Zhong1 guo2] /China/Middle Kingdom/" {
+		t.Errorf("unexpected entry %s", FormatLine(ci))
+	}
+}
+
+func views_LineErrors(t *testing.T) {
+	src := "中国 中国 [Zhong1 guo2] /China/\nbroken line\n你好 你好 [ni3 hao3] /hello/\n吃饭 吃饭 [chī fàn] /to eat/"
+
+	_, expectedErr := ReadDictionary(strings.NewReader(src))
+	vs, err := NewViews([]byte(src))
+
+	if vs.Len() != 2 {
+		t.Errorf("expected 2 views, got %d", vs.Len())
+	}
+	if err == nil || err.Error() != expectedErr.Error() {
+		t.Errorf("expected error %v, got %v", expectedErr, err)
+	}
+}
+
+func views_Lookup(t *testing.T) {
+	vs, err := NewViews(readFile(t, sampleDictionary))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := loadSampleIndex(t)
+
+	for _, ci := range idx.Entries() {
+		for _, w := range []string{ci.Jiantizi, ci.Fantizi} {
+			if got, expected := vs.Lookup(w), idx.Lookup(w); !reflect.DeepEqual(got, expected) {
+				t.Errorf("lookup %s: expected %v, got %v", w, headwords(expected), headwords(got))
+			}
+		}
+	}
+
+	if got := vs.Lookup("不存在"); len(got) != 0 {
+		t.Errorf("expected no entries, got %v", headwords(got))
+	}
+}
+
+func views_Open(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cedict.u8")
+	if err := os.WriteFile(path, readFile(t, sampleDictionary), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	vs, err := OpenViews(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := vs.Lookup("中国")
+	if err := vs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// materialized entries outlive the mapping
+	if len(entries) != 1 || entries[0].Gloss[0] != "China" {
+		t.Errorf("unexpected entries %v", entries)
+	}
+
+	if _, err := OpenViews(filepath.Join(t.TempDir(), "missing.u8")); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.u8")
+	os.WriteFile(empty, nil, 0o644)
+	vs, err = OpenViews(empty)
+	if err != nil || vs.Len() != 0 {
+		t.Errorf("expected an empty dictionary, got %v", err)
+	}
+	vs.Close()
+}
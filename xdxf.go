@@ -0,0 +1,124 @@
+package cccedictparser
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+)
+
+// XDXFOptions fills the meta_info of an XDXF dictionary.
+type XDXFOptions struct {
+	// Title is the short name of the dictionary, "CC-CEDICT" when empty.
+	Title       string
+	FullTitle   string
+	Description string
+	// Version is written as file_ver.
+	Version string
+	// Date is written as creation_date, XDXF expects DD-MM-YYYY.
+	Date string
+	// SourceURL is written as dict_src_url.
+	SourceURL string
+}
+
+type xdxfMeta struct {
+	XMLName     xml.Name `xml:"meta_info"`
+	Title       string   `xml:"title"`
+	FullTitle   string   `xml:"full_title,omitempty"`
+	Description string   `xml:"description,omitempty"`
+	Version     string   `xml:"file_ver,omitempty"`
+	Date        string   `xml:"creation_date,omitempty"`
+	SourceURL   string   `xml:"dict_src_url,omitempty"`
+}
+
+// xdxfArticle is one entry in the logical format: the headwords as keys, then
+// a definition holding the transcription and one definition per sense.
+type xdxfArticle struct {
+	XMLName xml.Name `xml:"ar"`
+	Keys    []string `xml:"k"`
+	Def     xdxfDef  `xml:"def"`
+}
+
+type xdxfDef struct {
+	Transcription string    `xml:"tr,omitempty"`
+	Senses        []xdxfDef `xml:"def"`
+	Text          string    `xml:"deftext,omitempty"`
+}
+
+func xdxfArticleFor(ci Ci) xdxfArticle {
+	ar := xdxfArticle{Keys: []string{ci.Jiantizi}}
+	if ci.Fantizi != ci.Jiantizi {
+		ar.Keys = append(ar.Keys, ci.Fantizi)
+	}
+
+	ar.Def.Transcription = ci.DiacriticPinyin()
+	for _, g := range ci.Gloss {
+		ar.Def.Senses = append(ar.Def.Senses, xdxfDef{Text: g})
+	}
+	return ar
+}
+
+// WriteXDXF writes entries as an XDXF dictionary (logical format, revision
+// 34) from Chinese to English. Every entry is an article keyed by its
+// simplified and traditional headwords, with the pinyin in tone marks as
+// transcription and one definition per sense.
+func WriteXDXF(w io.Writer, entries []Ci, opts XDXFOptions) error {
+	bw := bufio.NewWriter(w)
+	if _, err := io.WriteString(bw, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(bw)
+	enc.Indent("", " ")
+
+	root := xml.StartElement{
+		Name: xml.Name{Local: "xdxf"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "lang_from"}, Value: "ZHO"},
+			{Name: xml.Name{Local: "lang_to"}, Value: "ENG"},
+			{Name: xml.Name{Local: "format"}, Value: "logical"},
+			{Name: xml.Name{Local: "revision"}, Value: "034"},
+		},
+	}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+
+	meta := xdxfMeta{
+		Title:       opts.Title,
+		FullTitle:   opts.FullTitle,
+		Description: opts.Description,
+		Version:     opts.Version,
+		Date:        opts.Date,
+		SourceURL:   opts.SourceURL,
+	}
+	if meta.Title == "" {
+		meta.Title = "CC-CEDICT"
+	}
+	if err := enc.Encode(meta); err != nil {
+		return err
+	}
+
+	lexicon := xml.StartElement{Name: xml.Name{Local: "lexicon"}}
+	if err := enc.EncodeToken(lexicon); err != nil {
+		return err
+	}
+	for _, ci := range entries {
+		if err := enc.Encode(xdxfArticleFor(ci)); err != nil {
+			return err
+		}
+	}
+
+	if err := enc.EncodeToken(lexicon.End()); err != nil {
+		return err
+	}
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(bw, "\n"); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,71 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestXDXF(t *testing.T) {
+	tests := []testItem{
+		{Name: "xdxf_Articles", Test: xdxf_Articles},
+		{Name: "xdxf_Schema", Test: xdxf_Schema},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func xdxf_Articles(t *testing.T) {
+	entries := loadSample(t)
+
+	var buf bytes.Buffer
+	if err := WriteXDXF(&buf, entries, XDXFOptions{Description: "a & b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		LangFrom string        `xml:"lang_from,attr"`
+		Title    string        `xml:"meta_info>title"`
+		Desc     string        `xml:"meta_info>description"`
+		Articles []xdxfArticle `xml:"lexicon>ar"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.LangFrom != "ZHO" || doc.Title != "CC-CEDICT" || doc.Desc != "a & b" {
+		t.Errorf("unexpected meta_info %+v", doc)
+	}
+	if len(doc.Articles) != len(entries) {
+		t.Fatalf("expected %d articles, got %d", len(entries), len(doc.Articles))
+	}
+
+	for i, ci := range entries {
+		ar := doc.Articles[i]
+		if ar.Keys[0] != ci.Jiantizi || (ci.Fantizi != ci.Jiantizi && (len(ar.Keys) != 2 || ar.Keys[1] != ci.Fantizi)) {
+			t.Errorf("%s: unexpected keys %v", ci.Jiantizi, ar.Keys)
+		}
+		if ar.Def.Transcription != ci.DiacriticPinyin() {
+			t.Errorf("%s: unexpected transcription %s", ci.Jiantizi, ar.Def.Transcription)
+		}
+
+		senses := make([]string, 0, len(ar.Def.Senses))
+		for _, s := range ar.Def.Senses {
+			senses = append(senses, s.Text)
+		}
+		if strings.Join(senses, "/") != strings.Join(ci.Gloss, "/") {
+			t.Errorf("%s: expected senses %v, got %v", ci.Jiantizi, ci.Gloss, senses)
+		}
+	}
+}
+
+func xdxf_Schema(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteXDXF(&buf, loadSample(t), XDXFOptions{FullTitle: "CC-CEDICT <sample>", Date: "18-10-2026"}); err != nil {
+		t.Fatal(err)
+	}
+	validateXML(t, buf.Bytes(), "testdata/xdxf.dtd", "xdxf")
+}
@@ -0,0 +1,406 @@
+package cccedictparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// dtd is the subset of a document type definition the exporter tests need:
+// element content models and attribute lists, without entities or notations.
+type dtd struct {
+	Elements map[string]*dtdElement
+}
+
+type dtdElement struct {
+	Name string
+	// Mixed is set for (#PCDATA | ...)* content, which may hold text.
+	Mixed bool
+	Empty bool
+	Any   bool
+	// Model matches the names of the children, each followed by a comma.
+	Model *regexp.Regexp
+	Attrs map[string]dtdAttr
+}
+
+type dtdAttr struct {
+	// Type is CDATA, ID, NMTOKEN or ENUM, with the choices in Values.
+	Type     string
+	Values   []string
+	Required bool
+	// Fixed is the only value allowed, when set.
+	Fixed string
+}
+
+var (
+	dtd_comment   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	dtd_decl      = regexp.MustCompile(`(?s)<!(ELEMENT|ATTLIST)\s+(\S+)\s+(.*?)>`)
+	dtd_model_tok = regexp.MustCompile(`#PCDATA|[\w.:-]+|[(),|?*+]`)
+	dtd_attr_tok  = regexp.MustCompile(`"[^"]*"|\([^)]*\)|[^\s"()]+`)
+	xml_nmtoken   = regexp.MustCompile(`^[\w.:-]+$`)
+	xml_ncname    = regexp.MustCompile(`^[A-Za-z_][\w.-]*$`)
+)
+
+// loadDTD parses the schema at path and fails the test when it cannot.
+func loadDTD(t *testing.T, path string) *dtd {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := parseDTD(string(data))
+	if err != nil {
+		t.Fatalf("%s: %s", path, err.Error())
+	}
+	return d
+}
+
+func parseDTD(src string) (*dtd, error) {
+	d := &dtd{Elements: map[string]*dtdElement{}}
+	decls := dtd_decl.FindAllStringSubmatch(dtd_comment.ReplaceAllString(src, ""), -1)
+	if len(decls) == 0 {
+		return nil, errors.New("no declarations")
+	}
+
+	// Elements first, so attribute lists may come in any order.
+	for _, m := range decls {
+		if m[1] != "ELEMENT" {
+			continue
+		}
+		if _, ok := d.Elements[m[2]]; ok {
+			return nil, fmt.Errorf("element %s declared twice", m[2])
+		}
+		el, err := parseContentModel(m[2], strings.TrimSpace(m[3]))
+		if err != nil {
+			return nil, err
+		}
+		d.Elements[m[2]] = el
+	}
+	for _, m := range decls {
+		if m[1] != "ATTLIST" {
+			continue
+		}
+		el, ok := d.Elements[m[2]]
+		if !ok {
+			return nil, fmt.Errorf("attribute list for undeclared element %s", m[2])
+		}
+		if err := parseAttList(el, m[3]); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// parseContentModel turns a content model into a regexp over the names of
+// the children, so (a, b?)+ becomes ^(?:(?:a,)(?:b,)?)+$.
+func parseContentModel(name, model string) (*dtdElement, error) {
+	el := &dtdElement{Name: name, Attrs: map[string]dtdAttr{}}
+	switch model {
+	case "EMPTY":
+		el.Empty = true
+		return el, nil
+	case "ANY":
+		el.Any = true
+		return el, nil
+	}
+
+	toks := dtd_model_tok.FindAllString(model, -1)
+	if strings.Join(toks, "") != strings.Join(strings.Fields(model), "") {
+		return nil, fmt.Errorf("element %s: bad content model %q", name, model)
+	}
+	if len(toks) > 1 && toks[1] == "#PCDATA" {
+		// Mixed content is (#PCDATA) or (#PCDATA | a | b)*.
+		el.Mixed = true
+		if len(toks) > 3 && toks[len(toks)-1] != "*" {
+			return nil, fmt.Errorf("element %s: mixed content must end in )*", name)
+		}
+		toks = toks[2:]
+		if len(toks) > 0 && toks[0] == "|" {
+			toks = append([]string{"("}, toks[1:]...)
+		} else {
+			toks = nil
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for _, tok := range toks {
+		switch tok {
+		case "(":
+			b.WriteString("(?:")
+		case ")", "|", "?", "*", "+":
+			b.WriteString(tok)
+		case ",":
+		case "#PCDATA":
+			return nil, fmt.Errorf("element %s: #PCDATA must come first", name)
+		default:
+			b.WriteString("(?:" + regexp.QuoteMeta(tok) + ",)")
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("element %s: %w", name, err)
+	}
+	el.Model = re
+	return el, nil
+}
+
+func parseAttList(el *dtdElement, list string) error {
+	toks := dtd_attr_tok.FindAllString(list, -1)
+	for len(toks) > 0 {
+		if len(toks) < 3 {
+			return fmt.Errorf("element %s: incomplete attribute list", el.Name)
+		}
+		name, typ, def := toks[0], toks[1], toks[2]
+		toks = toks[3:]
+
+		var a dtdAttr
+		switch {
+		case typ == "CDATA", typ == "ID", typ == "NMTOKEN":
+			a.Type = typ
+		case strings.HasPrefix(typ, "("):
+			a.Type = "ENUM"
+			for v := range strings.SplitSeq(strings.Trim(typ, "()"), "|") {
+				a.Values = append(a.Values, strings.TrimSpace(v))
+			}
+		default:
+			return fmt.Errorf("element %s: unsupported type %s for %s", el.Name, typ, name)
+		}
+
+		switch def {
+		case "#REQUIRED":
+			a.Required = true
+		case "#IMPLIED":
+		case "#FIXED":
+			if len(toks) == 0 || !strings.HasPrefix(toks[0], `"`) {
+				return fmt.Errorf("element %s: #FIXED %s without a value", el.Name, name)
+			}
+			a.Fixed = strings.Trim(toks[0], `"`)
+			toks = toks[1:]
+		default:
+			return fmt.Errorf("element %s: unsupported default %s for %s", el.Name, def, name)
+		}
+		el.Attrs[name] = a
+	}
+	return nil
+}
+
+func xmlName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// validate checks that data is a document with the given root element that
+// conforms to d. It returns every violation found.
+func (d *dtd) validate(data []byte, root string) []error {
+	type frame struct {
+		el *dtdElement
+		// children lists the names of the children, each followed by a comma.
+		children string
+	}
+
+	var (
+		errs  []error
+		stack []*frame
+		ids   = map[string]bool{}
+		seen  bool
+	)
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		// RawToken keeps the xml: prefix on attributes, which DTDs see as
+		// part of the name.
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return append(errs, err)
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			name := xmlName(tok.Name)
+			if len(stack) == 0 {
+				if seen || name != root {
+					errs = append(errs, fmt.Errorf("root element %s, expected %s", name, root))
+				}
+				seen = true
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children += name + ","
+			}
+
+			el, ok := d.Elements[name]
+			if !ok {
+				errs = append(errs, fmt.Errorf("undeclared element %s", name))
+				el = &dtdElement{Name: name, Any: true}
+			}
+			errs = append(errs, el.validateAttrs(tok.Attr, ids)...)
+			stack = append(stack, &frame{el: el})
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].el.Name != xmlName(tok.Name) {
+				return append(errs, fmt.Errorf("unexpected end of %s", xmlName(tok.Name)))
+			}
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			switch {
+			case f.el.Any:
+			case f.el.Empty:
+				if f.children != "" {
+					errs = append(errs, fmt.Errorf("element %s must be empty", f.el.Name))
+				}
+			case !f.el.Model.MatchString(f.children):
+				errs = append(errs, fmt.Errorf("element %s: children (%s) do not match its content model",
+					f.el.Name, strings.TrimSuffix(f.children, ",")))
+			}
+		case xml.CharData:
+			if len(stack) == 0 || strings.TrimSpace(string(tok)) == "" {
+				continue
+			}
+			if el := stack[len(stack)-1].el; !el.Mixed && !el.Any {
+				errs = append(errs, fmt.Errorf("element %s cannot hold text", el.Name))
+			}
+		}
+	}
+	if !seen {
+		errs = append(errs, errors.New("no root element"))
+	}
+	for _, f := range stack {
+		errs = append(errs, fmt.Errorf("element %s is not closed", f.el.Name))
+	}
+	return errs
+}
+
+func (el *dtdElement) validateAttrs(attrs []xml.Attr, ids map[string]bool) []error {
+	var errs []error
+	present := map[string]bool{}
+	for _, attr := range attrs {
+		name := xmlName(attr.Name)
+		present[name] = true
+		a, ok := el.Attrs[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("element %s: undeclared attribute %s", el.Name, name))
+			continue
+		}
+
+		v := attr.Value
+		switch {
+		case a.Fixed != "" && v != a.Fixed:
+			errs = append(errs, fmt.Errorf("element %s: %s must be %q, got %q", el.Name, name, a.Fixed, v))
+		case a.Type == "ENUM" && !slices.Contains(a.Values, v):
+			errs = append(errs, fmt.Errorf("element %s: %s %q is not one of %s", el.Name, name, v, strings.Join(a.Values, ", ")))
+		case a.Type == "NMTOKEN" && !xml_nmtoken.MatchString(v):
+			errs = append(errs, fmt.Errorf("element %s: %s %q is not a name token", el.Name, name, v))
+		case a.Type == "ID" && !xml_ncname.MatchString(v):
+			errs = append(errs, fmt.Errorf("element %s: %s %q is not a valid ID", el.Name, name, v))
+		case a.Type == "ID" && ids[v]:
+			errs = append(errs, fmt.Errorf("element %s: duplicate ID %q", el.Name, v))
+		}
+		if a.Type == "ID" {
+			ids[v] = true
+		}
+	}
+	for name, a := range el.Attrs {
+		if a.Required && !present[name] {
+			errs = append(errs, fmt.Errorf("element %s: missing attribute %s", el.Name, name))
+		}
+	}
+	return errs
+}
+
+// validateXML fails the test when data does not conform to the schema at path.
+func validateXML(t *testing.T, data []byte, path, root string) {
+	t.Helper()
+	for _, err := range loadDTD(t, path).validate(data, root) {
+		t.Error(err)
+	}
+}
+
+func TestDTD(t *testing.T) {
+	tests := []testItem{
+		{Name: "dtd_ContentModel", Test: dtd_ContentModel},
+		{Name: "dtd_Rejects", Test: dtd_Rejects},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func dtd_ContentModel(t *testing.T) {
+	tests := []testCase[string]{
+		{Sentence: "(#PCDATA)", Expected: "^$"},
+		{Sentence: "(#PCDATA | a | b)*", Expected: "^(?:(?:a,)|(?:b,))*$"},
+		{Sentence: "(a, b?)+", Expected: "^(?:(?:a,)(?:b,)?)+$"},
+		{Sentence: "(meta_info, lexicon)", Expected: "^(?:(?:meta_info,)(?:lexicon,))$"},
+	}
+
+	for _, tc := range tests {
+		el, err := parseContentModel("x", tc.Sentence)
+		if err != nil {
+			t.Errorf("%s: %s", tc.Sentence, err.Error())
+			continue
+		}
+		if got := el.Model.String(); got != tc.Expected {
+			t.Errorf("%s: got %s, expected %s", tc.Sentence, got, tc.Expected)
+		}
+	}
+}
+
+// dtd_Rejects makes sure the validator catches each kind of violation, so the
+// exporter tests cannot pass by accident.
+func dtd_Rejects(t *testing.T) {
+	d, err := parseDTD(`
+<!ELEMENT doc (head, item+)>
+<!ATTLIST doc xmlns CDATA #FIXED "urn:doc">
+<!ELEMENT head (#PCDATA)>
+<!ELEMENT item (#PCDATA | b)*>
+<!ATTLIST item
+    xml:id ID #REQUIRED
+    kind (x | y) #IMPLIED
+    n NMTOKEN #IMPLIED>
+<!ELEMENT b (#PCDATA)>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const valid = `<doc xmlns="urn:doc"><head>h</head><item xml:id="i1" kind="x" n="1">a <b>b</b></item></doc>`
+	if errs := d.validate([]byte(valid), "doc"); len(errs) > 0 {
+		t.Fatalf("valid document rejected: %v", errs)
+	}
+
+	tests := []testCase[string]{
+		{Sentence: "wrong root", Expected: `<head>h</head>`},
+		{Sentence: "missing child", Expected: `<doc><head>h</head></doc>`},
+		{Sentence: "children out of order", Expected: `<doc><item xml:id="i1"/><head>h</head></doc>`},
+		{Sentence: "undeclared element", Expected: `<doc><head>h</head><item xml:id="i1"><c/></item></doc>`},
+		{Sentence: "text in element content", Expected: `<doc>t<head>h</head><item xml:id="i1"/></doc>`},
+		{Sentence: "element in text content", Expected: `<doc><head><b>h</b></head><item xml:id="i1"/></doc>`},
+		{Sentence: "missing attribute", Expected: `<doc><head>h</head><item/></doc>`},
+		{Sentence: "undeclared attribute", Expected: `<doc><head>h</head><item xml:id="i1" lang="en"/></doc>`},
+		{Sentence: "fixed attribute", Expected: `<doc xmlns="urn:other"><head>h</head><item xml:id="i1"/></doc>`},
+		{Sentence: "enumerated attribute", Expected: `<doc><head>h</head><item xml:id="i1" kind="z"/></doc>`},
+		{Sentence: "name token", Expected: `<doc><head>h</head><item xml:id="i1" n="a b"/></doc>`},
+		{Sentence: "invalid ID", Expected: `<doc><head>h</head><item xml:id="1"/></doc>`},
+		{Sentence: "duplicate ID", Expected: `<doc><head>h</head><item xml:id="i1"/><item xml:id="i1"/></doc>`},
+		{Sentence: "not well-formed", Expected: `<doc><head>h</head>`},
+	}
+
+	for _, tc := range tests {
+		if errs := d.validate([]byte(tc.Expected), "doc"); len(errs) == 0 {
+			t.Errorf("%s: accepted %s", tc.Sentence, tc.Expected)
+		}
+	}
+}
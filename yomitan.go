@@ -0,0 +1,155 @@
+package cccedictparser
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// yomitan_bank_size is the number of terms per term_bank_N.json.
+const yomitan_bank_size = 10000
+
+// YomitanOptions fills the index.json of a Yomitan dictionary.
+type YomitanOptions struct {
+	// Title identifies the dictionary in Yomitan, "CC-CEDICT" when empty.
+	Title string
+	// Revision must change for Yomitan to accept an update, "1" when empty.
+	Revision    string
+	Author      string
+	URL         string
+	Description string
+	Attribution string
+}
+
+type yomitanIndex struct {
+	Title          string `json:"title"`
+	Revision       string `json:"revision"`
+	Format         int    `json:"format"`
+	Sequenced      bool   `json:"sequenced"`
+	Author         string `json:"author,omitempty"`
+	URL            string `json:"url,omitempty"`
+	Description    string `json:"description,omitempty"`
+	Attribution    string `json:"attribution,omitempty"`
+	SourceLanguage string `json:"sourceLanguage"`
+	TargetLanguage string `json:"targetLanguage"`
+}
+
+// yomitanTerms returns the term bank rows of ci: one for the simplified
+// headword and one for the traditional headword when it differs. Rows are
+// [term, reading, definition tags, rules, score, glossary, sequence, term tags].
+func yomitanTerms(ci Ci, sequence int) [][]any {
+	glossary := ci.Gloss
+	if glossary == nil {
+		glossary = []string{}
+	}
+	tags := strings.Join(ci.Tags(), " ")
+	reading := ci.DiacriticPinyin()
+
+	rows := [][]any{{ci.Jiantizi, reading, tags, "", 0, glossary, sequence, "simp"}}
+	if ci.Fantizi != ci.Jiantizi {
+		rows = append(rows, []any{ci.Fantizi, reading, tags, "", 0, glossary, sequence, "trad"})
+	} else {
+		rows[0][7] = "simp trad"
+	}
+	return rows
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// WriteYomitan writes entries as a Yomitan (format 3) dictionary zip: an
+// index.json, term_bank_N.json files and a tag_bank_1.json. Readings are
+// pinyin with tone marks and usage markers such as (coll.) or (idiom) become
+// definition tags.
+func WriteYomitan(w io.Writer, entries []Ci, opts YomitanOptions) error {
+	index := yomitanIndex{
+		Title:          opts.Title,
+		Revision:       opts.Revision,
+		Format:         3,
+		Sequenced:      true,
+		Author:         opts.Author,
+		URL:            opts.URL,
+		Description:    opts.Description,
+		Attribution:    opts.Attribution,
+		SourceLanguage: "zh",
+		TargetLanguage: "en",
+	}
+	if index.Title == "" {
+		index.Title = "CC-CEDICT"
+	}
+	if index.Revision == "" {
+		index.Revision = "1"
+	}
+
+	zw := zip.NewWriter(w)
+
+	if err := writeZipJSON(zw, "index.json", index); err != nil {
+		return err
+	}
+
+	used := make(map[string]bool)
+	bank := make([][]any, 0, yomitan_bank_size)
+	bankNo := 0
+
+	flush := func() error {
+		if len(bank) == 0 {
+			return nil
+		}
+		bankNo++
+		err := writeZipJSON(zw, fmt.Sprintf("term_bank_%d.json", bankNo), bank)
+		bank = bank[:0]
+		return err
+	}
+
+	for i, ci := range entries {
+		for _, tag := range ci.Tags() {
+			used[tag] = true
+		}
+		for _, row := range yomitanTerms(ci, i+1) {
+			bank = append(bank, row)
+			if len(bank) == yomitan_bank_size {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	// [name, category, order, notes, score]
+	tags := [][]any{
+		{"simp", "form", 0, "simplified characters", 0},
+		{"trad", "form", 0, "traditional characters", 0},
+	}
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		category := ""
+		switch name {
+		case "archaic", "old", "classical":
+			category = "archaism"
+		}
+		tags = append(tags, []any{name, category, 0, gloss_tag_notes[name], 0})
+	}
+
+	if err := writeZipJSON(zw, "tag_bank_1.json", tags); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,119 @@
+package cccedictparser
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+func TestYomitan(t *testing.T) {
+	tests := []testItem{
+		{Name: "yomitan_Index", Test: yomitan_Index},
+		{Name: "yomitan_TermBank", Test: yomitan_TermBank},
+		{Name: "yomitan_TagBank", Test: yomitan_TagBank},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func readYomitanZip(t *testing.T) map[string][]byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteYomitan(&buf, loadSample(t), YomitanOptions{Revision: "2026-10-18"}); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = data
+	}
+	return files
+}
+
+func yomitan_Index(t *testing.T) {
+	files := readYomitanZip(t)
+
+	var index map[string]any
+	if err := json.Unmarshal(files["index.json"], &index); err != nil {
+		t.Fatal(err)
+	}
+
+	if index["title"] != "CC-CEDICT" || index["revision"] != "2026-10-18" || index["format"] != float64(3) || index["sequenced"] != true {
+		t.Errorf("unexpected index.json: %s", string(files["index.json"]))
+	}
+}
+
+func yomitan_TermBank(t *testing.T) {
+	files := readYomitanZip(t)
+
+	var terms [][]any
+	if err := json.Unmarshal(files["term_bank_1.json"], &terms); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := files["term_bank_2.json"]; ok {
+		t.Error("sample should fit a single term bank")
+	}
+
+	byTerm := make(map[string][]any)
+	for _, row := range terms {
+		if len(row) != 8 {
+			t.Fatalf("expected 8 fields per term, got %v", row)
+		}
+		byTerm[row[0].(string)] = row
+	}
+
+	simp, trad := byTerm["中国"], byTerm["中國"]
+	if simp == nil || trad == nil {
+		t.Fatal("expected simplified and traditional terms for 中国")
+	}
+	if simp[1] != "Zhōng guó" || simp[6] != trad[6] {
+		t.Errorf("unexpected term rows %v / %v", simp, trad)
+	}
+
+	idiom := byTerm["画蛇添足"]
+	if idiom[2] != "idiom" {
+		t.Errorf("expected idiom definition tag, got %v", idiom[2])
+	}
+
+	if byTerm["人"][7] != "simp trad" {
+		t.Errorf("expected simp trad term tags for 人, got %v", byTerm["人"][7])
+	}
+}
+
+func yomitan_TagBank(t *testing.T) {
+	files := readYomitanZip(t)
+
+	var tags [][]any
+	if err := json.Unmarshal(files["tag_bank_1.json"], &tags); err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]bool)
+	for _, v := range tags {
+		names[v[0].(string)] = true
+	}
+
+	for _, expected := range []string{"simp", "trad", "idiom", "coll", "slang", "loanword"} {
+		if !names[expected] {
+			t.Errorf("tag bank misses %s", expected)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package cccedictparser
+
+import "strings"
+
+var zhuyin_initials = map[string]string{
+	`b`: `ㄅ`, `p`: `ㄆ`, `m`: `ㄇ`, `f`: `ㄈ`,
+	`d`: `ㄉ`, `t`: `ㄊ`, `n`: `ㄋ`, `l`: `ㄌ`,
+	`g`: `ㄍ`, `k`: `ㄎ`, `h`: `ㄏ`,
+	`j`: `ㄐ`, `q`: `ㄑ`, `x`: `ㄒ`,
+	`zh`: `ㄓ`, `ch`: `ㄔ`, `sh`: `ㄕ`, `r`: `ㄖ`,
+	`z`: `ㄗ`, `c`: `ㄘ`, `s`: `ㄙ`,
+}
+
+// keyed by PinyinV1.Final
+var zhuyin_finals = map[string]string{
+	`a`: `ㄚ`, `o`: `ㄛ`, `e`: `ㄜ`, `ai`: `ㄞ`, `ei`: `ㄟ`, `ao`: `ㄠ`, `ou`: `ㄡ`,
+	`an`: `ㄢ`, `en`: `ㄣ`, `ang`: `ㄤ`, `eng`: `ㄥ`, `ong`: `ㄨㄥ`, `er`: `ㄦ`,
+	`i`: `ㄧ`, `ia`: `ㄧㄚ`, `io`: `ㄧㄛ`, `ie`: `ㄧㄝ`, `iao`: `ㄧㄠ`, `iu`: `ㄧㄡ`,
+	`ian`: `ㄧㄢ`, `in`: `ㄧㄣ`, `iang`: `ㄧㄤ`, `ing`: `ㄧㄥ`, `iong`: `ㄩㄥ`,
+	`u`: `ㄨ`, `ua`: `ㄨㄚ`, `uo`: `ㄨㄛ`, `uai`: `ㄨㄞ`, `ui`: `ㄨㄟ`,
+	`uan`: `ㄨㄢ`, `un`: `ㄨㄣ`, `uang`: `ㄨㄤ`, `ueng`: `ㄨㄥ`,
+	`v`: `ㄩ`, `ve`: `ㄩㄝ`, `van`: `ㄩㄢ`, `vn`: `ㄩㄣ`,
+	// erhua syllable
+	`r`: `ㄦ`,
+}
+
+var zhuyin_tone_marks = []string{
+	None: ``,
+	T1:   ``,
+	T2:   `ˊ`,
+	T3:   `ˇ`,
+	T4:   `ˋ`,
+}
+
+// initials whose "i" final is not written in zhuyin
+var zhuyin_syllabic_initials = map[string]bool{
+	`zh`: true, `ch`: true, `sh`: true, `r`: true, `z`: true, `c`: true, `s`: true,
+}
+
+// Zhuyin returns the syllable in zhuyin (bopomofo), e.g. "ㄓㄨㄥ" for zhong1
+// and "˙ㄇㄣ" for men5. Letters, punctuation and unknown syllables are
+// returned unchanged.
+func (p PinyinV1) Zhuyin() string {
+	if p.Type != Normal {
+		return p.Sound
+	}
+
+	initial := p.Initial()
+	final := p.Final()
+
+	var body string
+	if zhuyin_syllabic_initials[initial] && final == "i" {
+		body = zhuyin_initials[initial]
+	} else {
+		f, ok := zhuyin_finals[final]
+		if !ok {
+			return p.Sound
+		}
+		body = zhuyin_initials[initial] + f
+	}
+
+	if p.Tone == T5 {
+		return "˙" + body
+	}
+	if int(p.Tone) < len(zhuyin_tone_marks) {
+		return body + zhuyin_tone_marks[p.Tone]
+	}
+	return body
+}
+
+// Zhuyin returns the syllables of the word in zhuyin separated by spaces.
+func (p PinyinV2) Zhuyin() string {
+	syllables := make([]string, 0, len(p.Word))
+	for _, v := range p.Word {
+		syllables = append(syllables, v.Zhuyin())
+	}
+	return strings.Join(syllables, " ")
+}
+
+// Zhuyin returns the reading of ci in zhuyin, one syllable per space
+// separated group, e.g. "ㄓㄨㄥ ㄍㄨㄛˊ".
+func (ci Ci) Zhuyin() string {
+	words := make([]string, 0, len(ci.Pinyin))
+	for _, w := range ci.Pinyin {
+		words = append(words, w.Zhuyin())
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,57 @@
+package cccedictparser
+
+import "testing"
+
+func TestZhuyin(t *testing.T) {
+	tests := []testItem{
+		{Name: "zhuyin_Syllable", Test: zhuyin_Syllable},
+		{Name: "zhuyin_Ci", Test: zhuyin_Ci},
+	}
+
+	for _, v := range tests {
+		t.Run(v.Name, v.Test)
+	}
+}
+
+func zhuyin_Syllable(t *testing.T) {
+	cases := []testCase[string]{
+		{Sentence: "zhong1", Expected: "ㄓㄨㄥ"},
+		{Sentence: "guo2", Expected: "ㄍㄨㄛˊ"},
+		{Sentence: "ni3", Expected: "ㄋㄧˇ"},
+		{Sentence: "shi4", Expected: "ㄕˋ"},
+		{Sentence: "si4", Expected: "ㄙˋ"},
+		{Sentence: "men5", Expected: "˙ㄇㄣ"},
+		{Sentence: "yi1", Expected: "ㄧ"},
+		{Sentence: "you3", Expected: "ㄧㄡˇ"},
+		{Sentence: "yong3", Expected: "ㄩㄥˇ"},
+		{Sentence: "wei4", Expected: "ㄨㄟˋ"},
+		{Sentence: "yuan2", Expected: "ㄩㄢˊ"},
+		{Sentence: "xue2", Expected: "ㄒㄩㄝˊ"},
+		{Sentence: "lu:4", Expected: "ㄌㄩˋ"},
+		{Sentence: "er2", Expected: "ㄦˊ"},
+		{Sentence: "r5", Expected: "˙ㄦ"},
+		{Sentence: "K", Expected: "K"},
+	}
+
+	for _, v := range cases {
+		ci, err := ParseLine("字 字 [" + v.Sentence + "] /gloss/")
+		if err != nil {
+			t.Errorf("error: %s. Pinyin %s", err.Error(), v.Sentence)
+			continue
+		}
+		if got := ci.Pinyin[0].Word[0].Zhuyin(); got != v.Expected {
+			t.Errorf("%s: expected %s, got %s", v.Sentence, v.Expected, got)
+		}
+	}
+}
+
+func zhuyin_Ci(t *testing.T) {
+	ci, err := ParseLine("中國人 中国人 [Zhong1 guo2 ren2] /Chinese person/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ci.Zhuyin(); got != "ㄓㄨㄥ ㄍㄨㄛˊ ㄖㄣˊ" {
+		t.Errorf("expected ㄓㄨㄥ ㄍㄨㄛˊ ㄖㄣˊ, got %s", got)
+	}
+}